@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"minigo/utils"
+)
+
+// 资源支持的请求方法
+const (
+	collectionMethods = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+	itemMethods       = "GET, PUT, DELETE, OPTIONS"
+)
+
+// fieldCapability 字段能力描述
+type fieldCapability struct {
+	Name      string   `json:"name"`                // 查询和更新时使用的字段名
+	Queryable bool     `json:"queryable"`           // 可作为列表查询条件
+	Creatable bool     `json:"creatable"`           // 创建时可提交
+	Updatable bool     `json:"updatable"`           // 可更新
+	Orderable bool     `json:"orderable"`           // 可排序
+	Required  bool     `json:"required"`            // 创建时必填
+	WriteOnce bool     `json:"write_once"`          // 仅创建时可设置，之后不可更新
+	Operators []string `json:"operators,omitempty"` // 作为查询条件时支持的操作符
+}
+
+// 列表查询条件支持的操作符及其查询参数形式
+var filterOperators = map[string]string{
+	"eq":       "{field}=value",
+	"contains": "{field}_contains=value",
+}
+
+// genericOptions 通用 OPTIONS 处理，返回 Allow 响应头，请求接受 JSON 时附带字段能力描述
+func genericOptions(c *gin.Context, model interface{}, allow string) {
+	c.Header("Allow", allow)
+
+	if !strings.Contains(c.GetHeader("Accept"), "application/json") {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	modelType, _, _ := utils.GetModelInfo(model)
+	c.JSON(http.StatusOK, gin.H{"methods": strings.Split(allow, ", "), "fields": fieldCapabilities(modelType)})
+}
+
+// genericMeta 资源元信息，供前端发现可查询、可排序、可更新的字段及查询条件支持的操作符
+func genericMeta(c *gin.Context, model interface{}) {
+	modelType, _, _ := utils.GetModelInfo(model)
+	fields := fieldCapabilities(modelType)
+
+	queryable := make([]string, 0)
+	orderable := make([]string, 0)
+	updatable := make([]string, 0)
+	for _, field := range fields {
+		if field.Queryable {
+			queryable = append(queryable, field.Name)
+		}
+		if field.Orderable {
+			orderable = append(orderable, field.Name)
+		}
+		if field.Updatable {
+			updatable = append(updatable, field.Name)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"queryable": queryable,
+		"orderable": orderable,
+		"updatable": updatable,
+		"operators": filterOperators,
+		"fields":    fields,
+	})
+}
+
+// fieldCapabilities 根据 ctags 获取模型各字段的能力描述
+func fieldCapabilities(modelType reflect.Type) []fieldCapability {
+	fields := make([]fieldCapability, 0, modelType.NumField())
+	creatableFields := utils.CreatableFields(modelType)
+	for _, field := range utils.StructFields(modelType) {
+		if field.Anonymous || !field.IsExported() {
+			continue
+		}
+
+		// 优先使用 ctags 字段名，其次为 json 字段名
+		jsonName := utils.JSONFieldName(field)
+		capability := fieldCapability{Name: jsonName, Required: utils.IsRequiredField(field)}
+		bindName := utils.BindFieldName(field)
+		capability.Creatable = bindName != "" && (creatableFields == nil || utils.ExistsIn(creatableFields, bindName))
+		if tag := field.Tag.Get("ctags"); tag != "" {
+			tags := strings.Split(tag, ",")
+			if tags[0] != "" {
+				capability.Name = tags[0]
+			}
+			capability.Queryable = utils.ExistsIn(tags[1:], "q") && !utils.ExistsIn(tags[1:], "encrypt")
+			capability.WriteOnce = utils.ExistsIn(tags[1:], "wo")
+			capability.Updatable = utils.ExistsIn(tags[1:], "u") && !capability.WriteOnce
+			capability.Orderable = utils.ExistsIn(tags[1:], "o")
+		}
+		if capability.Name == "" {
+			continue
+		}
+
+		// 列表始终可以按 id 排序
+		if capability.Name == "id" {
+			capability.Orderable = true
+		}
+		if capability.Queryable {
+			capability.Operators = []string{"eq", "contains"}
+		}
+		fields = append(fields, capability)
+	}
+	return fields
+}
@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestOptionsCapabilities(t *testing.T) {
+	r, _ := setupTest(t, chunkItem{})
+	path := "/api/chunk_items"
+
+	// 未声明接受 JSON 时只返回 Allow 响应头
+	w := request(r, http.MethodOptions, path, "")
+	expectStatus(t, w, http.StatusNoContent)
+	if allow := w.Header().Get("Allow"); allow != collectionMethods {
+		t.Fatalf("collection Allow = %q, want %q", allow, collectionMethods)
+	}
+	w = request(r, http.MethodOptions, path+"/1", "")
+	expectStatus(t, w, http.StatusNoContent)
+	if allow := w.Header().Get("Allow"); allow != itemMethods {
+		t.Fatalf("item Allow = %q, want %q", allow, itemMethods)
+	}
+
+	w = request(r, http.MethodOptions, path, "", "Accept", "application/json")
+	expectStatus(t, w, http.StatusOK)
+	if allow := w.Header().Get("Allow"); allow != collectionMethods {
+		t.Fatalf("collection Allow = %q, want %q", allow, collectionMethods)
+	}
+	body := decode(t, w)
+	if methods := body["methods"]; !reflect.DeepEqual(methods, []interface{}{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}) {
+		t.Fatalf("methods = %v", methods)
+	}
+
+	fields := map[string]map[string]interface{}{}
+	for _, field := range body["fields"].([]interface{}) {
+		field := field.(map[string]interface{})
+		fields[field["name"].(string)] = field
+	}
+	// json:"-" 的 deleted_at 不出现在能力描述中
+	if _, exists := fields["deleted_at"]; exists || len(fields) != 4 {
+		t.Fatalf("fields = %v", fields)
+	}
+	name := fields["name"]
+	if name["queryable"] != true || name["creatable"] != true || name["updatable"] != true || name["required"] != true || name["orderable"] != false {
+		t.Fatalf("name capability = %v", name)
+	}
+	if !reflect.DeepEqual(name["operators"], []interface{}{"eq", "contains"}) {
+		t.Fatalf("name operators = %v", name["operators"])
+	}
+	if id := fields["id"]; id["orderable"] != true || id["updatable"] != false || id["queryable"] != false {
+		t.Fatalf("id capability = %v", id)
+	}
+}
@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// batchChunker 批量操作分块提交，记录已提交块的记录范围
+type batchChunker struct {
+	size      int      // 每块记录数，<= 0 时不分块
+	total     int      // 记录总数
+	start     int      // 当前块第一条记录的序号
+	committed []string // 已提交块的记录范围，形如 0-99
+}
+
+// newBatchChunker 创建分块提交器
+func newBatchChunker(size, total int) *batchChunker {
+	return &batchChunker{size: size, total: total}
+}
+
+// advance 第 i 条记录处理完成后调用，到达块边界时提交当前事务并开启新事务，返回后续操作使用的数据库实例
+// 最后一块由事务中间件提交
+func (b *batchChunker) advance(c *gin.Context, db *gorm.DB, i int) (*gorm.DB, error) {
+	if b.size <= 0 || (i+1)%b.size != 0 || i+1 >= b.total {
+		return db, nil
+	}
+
+	tx, exists := c.Get("tx")
+	if !exists {
+		return db, nil
+	}
+	if err := tx.(*gorm.DB).Commit().Error; err != nil {
+		return nil, err
+	}
+	b.record(c, i)
+
+	// 新事务替换上下文中的事务，由事务中间件提交或回滚
+	next := c.MustGet("tx_db").(*gorm.DB).Begin()
+	if next.Error != nil {
+		return nil, next.Error
+	}
+	c.Set("tx", next)
+
+	// 沿用处理程序实例的上下文，保留查询超时和语句统计
+	return next.WithContext(db.Statement.Context), nil
+}
+
+// finish 所有记录处理完成后调用，将最后一块计入已提交的块，需在写入响应前调用
+func (b *batchChunker) finish(c *gin.Context) {
+	if b.size > 0 && b.total > 0 {
+		b.record(c, b.total-1)
+	}
+}
+
+// record 记录以第 end 条记录结束的块，并更新 X-Committed-Chunks 响应头
+// 处理失败时响应头只包含失败前已提交的块，未列出的记录均已回滚
+func (b *batchChunker) record(c *gin.Context, end int) {
+	b.committed = append(b.committed, fmt.Sprintf("%d-%d", b.start, end))
+	b.start = end + 1
+	c.Header("X-Committed-Chunks", strings.Join(b.committed, ","))
+}
@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"gorm.io/plugin/soft_delete"
+
+	"minigo/models"
+)
+
+// chunkItem 分块提交测试使用的模型，name 为必填字段
+type chunkItem struct {
+	models.BaseModel
+	DeletedAt soft_delete.DeletedAt `json:"-"`
+	Name      string                `json:"name" ctags:"name,q,u,required"`
+}
+
+func TestBatchChunkCommit(t *testing.T) {
+	db := openTestDataBase(t, chunkItem{})
+	r := newTestRouter(db, 0)
+	path := registerModel(r, db, chunkItem{}, WithBatchChunkSize(2))
+
+	w := request(r, http.MethodPost, path, `[{"name":"a"},{"name":"b"},{"name":"c"},{"name":"d"},{"name":"e"}]`)
+	expectStatus(t, w, http.StatusCreated)
+	if chunks := w.Header().Get("X-Committed-Chunks"); chunks != "0-1,2-3,4-4" {
+		t.Fatalf("committed chunks = %q, want 0-1,2-3,4-4", chunks)
+	}
+
+	// 第 4 条记录校验失败，之前已提交的块保留，当前块回滚
+	w = request(r, http.MethodPost, path, `[{"name":"f"},{"name":"g"},{"name":"h"},{},{"name":"i"}]`)
+	expectStatus(t, w, http.StatusUnprocessableEntity)
+	if chunks := w.Header().Get("X-Committed-Chunks"); chunks != "0-1" {
+		t.Fatalf("committed chunks = %q, want 0-1", chunks)
+	}
+
+	var names []string
+	db.Model(&chunkItem{}).Order("id").Pluck("name", &names)
+	if len(names) != 7 || names[5] != "f" || names[6] != "g" {
+		t.Fatalf("records = %v, want a-e, f, g", names)
+	}
+}
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"minigo/utils"
+)
+
+// 创建时主键或唯一键冲突的处理方式
+const (
+	conflictError  = "error"  // 返回错误（默认）
+	conflictIgnore = "ignore" // 保留已有记录，不做修改
+	conflictUpdate = "update" // 以请求中的值更新已有记录
+)
+
+// conflictPolicy 创建时的冲突处理，对应 gorm 的 clause.OnConflict
+type conflictPolicy struct {
+	schema          *schema.Schema // 模型的 gorm schema，用于字段名和列名的转换
+	action          string
+	conflictColumns []clause.Column // 判断冲突的列，需存在主键或唯一约束
+	updateColumns   []string        // update 时更新的列，为空时更新请求中提供的可更新字段
+	autoUpdate      []string        // 自动更新时间的列，update 时随之更新
+}
+
+// parseConflictPolicy 解析 on_conflict、conflict_columns、update_columns 参数，action 为 error 时返回 nil
+// 列名可以是字段名或数据库列名，conflict_columns 不能是加密字段，update_columns 只能是可更新（ctags 标记 u）的字段
+func parseConflictPolicy(db *gorm.DB, modelType reflect.Type, action, conflictColumns, updateColumns string) (*conflictPolicy, error) {
+	switch action {
+	case "", conflictError:
+		return nil, nil
+	case conflictIgnore, conflictUpdate:
+	default:
+		return nil, fmt.Errorf("invalid on_conflict: %s", action)
+	}
+	if conflictColumns == "" {
+		return nil, fmt.Errorf("conflict_columns is required when on_conflict is %s", action)
+	}
+
+	if err := db.Statement.Parse(db.Statement.Model); err != nil {
+		return nil, err
+	}
+	sch := db.Statement.Schema
+
+	policy := &conflictPolicy{schema: sch, action: action}
+	for _, name := range strings.Split(conflictColumns, ",") {
+		field := sch.LookUpField(strings.TrimSpace(name))
+		if field == nil || field.DBName == "" {
+			return nil, fmt.Errorf("invalid conflict column: %s", name)
+		}
+		if structField, ok := modelType.FieldByName(field.Name); ok && utils.IsEncryptedField(structField) {
+			return nil, fmt.Errorf("invalid conflict column: %s", name)
+		}
+		policy.conflictColumns = append(policy.conflictColumns, clause.Column{Name: field.DBName})
+	}
+
+	if action == conflictUpdate {
+		updatable := updatableColumns(sch, modelType)
+		if updateColumns != "" {
+			for _, name := range strings.Split(updateColumns, ",") {
+				field := sch.LookUpField(strings.TrimSpace(name))
+				if field == nil || !utils.ExistsIn(updatable, field.DBName) {
+					return nil, fmt.Errorf("invalid update column: %s", name)
+				}
+				policy.updateColumns = append(policy.updateColumns, field.DBName)
+			}
+		}
+		for _, field := range sch.Fields {
+			if field.AutoUpdateTime > 0 && field.DBName != "" {
+				policy.autoUpdate = append(policy.autoUpdate, field.DBName)
+			}
+		}
+	}
+	return policy, nil
+}
+
+// updatableColumns 获取 ctags 标记 u 的字段对应的数据库列名
+func updatableColumns(sch *schema.Schema, modelType reflect.Type) []string {
+	var columns []string
+	for _, structField := range utils.StructFields(modelType) {
+		tags := strings.Split(structField.Tag.Get("ctags"), ",")
+		if !utils.ExistsIn(tags[1:], "u") {
+			continue
+		}
+		if field := sch.LookUpField(structField.Name); field != nil && field.DBName != "" {
+			columns = append(columns, field.DBName)
+		}
+	}
+	return columns
+}
+
+// clause 生成单条记录的冲突子句，未指定 update_columns 时更新该记录请求中提供的可更新字段，没有可更新的列时不做修改
+func (p *conflictPolicy) clause(modelType reflect.Type, record map[string]interface{}) clause.OnConflict {
+	onConflict := clause.OnConflict{Columns: p.conflictColumns}
+
+	columns := p.updateColumns
+	if p.action == conflictUpdate && len(columns) == 0 {
+		updatable := updatableColumns(p.schema, modelType)
+		for _, structField := range utils.StructFields(modelType) {
+			name := strings.Split(structField.Tag.Get("json"), ",")[0]
+			if _, exists := record[name]; !exists || name == "" {
+				continue
+			}
+			if field := p.schema.LookUpField(structField.Name); field != nil && utils.ExistsIn(updatable, field.DBName) {
+				columns = append(columns, field.DBName)
+			}
+		}
+	}
+	if len(columns) == 0 {
+		onConflict.DoNothing = true
+		return onConflict
+	}
+
+	onConflict.DoUpdates = clause.AssignmentColumns(append(append([]string{}, columns...), p.autoUpdate...))
+	return onConflict
+}
+
+// reload 按冲突列重新读取记录，发生冲突时请求中的值未写入或只写入了部分列，响应返回数据库中的实际记录
+func (p *conflictPolicy) reload(db *gorm.DB, modelPtr interface{}) error {
+	conditions := make(map[string]interface{}, len(p.conflictColumns))
+	rv := reflect.ValueOf(modelPtr).Elem()
+	for _, column := range p.conflictColumns {
+		value, _ := p.schema.LookUpField(column.Name).ValueOf(db.Statement.Context, rv)
+		conditions[column.Name] = value
+	}
+	return db.Unscoped().Where(conditions).First(modelPtr).Error
+}
@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"gorm.io/plugin/soft_delete"
+
+	"minigo/models"
+)
+
+// skuItem 冲突处理测试使用的模型，code 唯一
+type skuItem struct {
+	models.BaseModel
+	DeletedAt soft_delete.DeletedAt `json:"-"`
+	Code      string                `json:"code" gorm:"type:varchar(32);uniqueIndex" ctags:"code,q,u"`
+	Name      string                `json:"name" ctags:"name,q,u"`
+	Price     int                   `json:"price" ctags:"price,q,u"`
+}
+
+func TestCreateOnConflict(t *testing.T) {
+	tests := []struct {
+		query string
+		want  skuItem
+	}{
+		{"", skuItem{Name: "old", Price: 1}},
+		{"?on_conflict=ignore&conflict_columns=code", skuItem{Name: "old", Price: 1}},
+		{"?on_conflict=update&conflict_columns=code&update_columns=name", skuItem{Name: "new", Price: 1}},
+		{"?on_conflict=update&conflict_columns=code", skuItem{Name: "new", Price: 2}},
+	}
+	for _, tt := range tests {
+		r, db := setupTest(t, skuItem{})
+		path := "/api/sku_items"
+		db.Create(&skuItem{Code: "A", Name: "old", Price: 1})
+
+		w := request(r, http.MethodPost, path+tt.query, `{"code":"A","name":"new","price":2}`)
+		if tt.query == "" {
+			// 默认返回错误，不修改已有记录
+			expectStatus(t, w, http.StatusBadRequest)
+		} else {
+			expectStatus(t, w, http.StatusCreated)
+		}
+
+		var items []skuItem
+		db.Find(&items)
+		if len(items) != 1 || items[0].Name != tt.want.Name || items[0].Price != tt.want.Price {
+			t.Fatalf("%q: records = %+v, want name %s price %d", tt.query, items, tt.want.Name, tt.want.Price)
+		}
+	}
+
+	// 冲突列不存在时拒绝请求
+	r, _ := setupTest(t, skuItem{})
+	w := request(r, http.MethodPost, "/api/sku_items?on_conflict=update&conflict_columns=missing", `{"code":"B"}`)
+	expectStatus(t, w, http.StatusBadRequest)
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"minigo/utils"
+)
+
+// csvFlushRows CSV 导出每写出多少行发送一次数据
+const csvFlushRows = 100
+
+// wantsCSV 判断列表是否以 CSV 导出，format=csv 或 Accept 为 text/csv 时导出
+func wantsCSV(c *gin.Context) bool {
+	if format := c.Query("format"); format != "" {
+		return strings.EqualFold(format, "csv")
+	}
+	return strings.Contains(c.GetHeader("Accept"), "text/csv")
+}
+
+// csvColumn CSV 导出的列
+type csvColumn struct {
+	header string
+	index  []int
+}
+
+// csvColumns 获取 CSV 导出的列，表头为 json 字段名，排除 json 为 "-" 的字段和密码字段
+func csvColumns(modelType reflect.Type) []csvColumn {
+	var columns []csvColumn
+	for _, field := range utils.StructFields(modelType) {
+		if field.Anonymous || !field.IsExported() {
+			continue
+		}
+		name := utils.JSONFieldName(field)
+		if name == "" || strings.Contains(strings.ToLower(field.Name), "password") {
+			continue
+		}
+		columns = append(columns, csvColumn{header: name, index: field.Index})
+	}
+	return columns
+}
+
+// csvValue 将字段值格式化为 CSV 单元格，时间使用 RFC3339，结构体、切片等复杂类型使用 JSON
+func csvValue(value reflect.Value) string {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return ""
+		}
+		value = value.Elem()
+	}
+
+	switch v := value.Interface().(type) {
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case []byte:
+		return string(v)
+	}
+	switch value.Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Map, reflect.Array:
+		data, err := json.Marshal(value.Interface())
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	default:
+		return fmt.Sprint(value.Interface())
+	}
+}
+
+// streamCSV 逐行扫描查询结果并以 CSV 写入响应，不在内存中构建完整的结果切片
+func streamCSV(c *gin.Context, query *gorm.DB, modelType reflect.Type, filename string) {
+	rows, err := query.Rows()
+	if err != nil {
+		logger := utils.GetLoggerByCtx(c)
+		logger.Ctx(c).Error("failed to query records", zap.Error(err))
+		utils.RespondError(c, http.StatusNotFound, utils.ErrCodeNotFound, "failed to query records", nil)
+		return
+	}
+	defer rows.Close()
+
+	columns := csvColumns(modelType)
+	record := make([]string, len(columns))
+	for i, column := range columns {
+		record[i] = column.header
+	}
+
+	// 响应头写出后无法再修改状态码，之后的错误只能记录日志并中断输出
+	// 标记为流式输出，中间件不缓存响应，导出的数据按行分批发送给客户端
+	utils.SetStreaming(c)
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, filename))
+	c.Status(http.StatusOK)
+	writer := csv.NewWriter(c.Writer)
+	writer.Write(record)
+
+	count := 0
+	for rows.Next() {
+		row := reflect.New(modelType)
+		err := query.ScanRows(rows, row.Interface())
+		if err == nil {
+			err = utils.DecryptFields(row.Interface())
+		}
+		if err == nil {
+			for i, column := range columns {
+				field, fieldErr := row.Elem().FieldByIndexErr(column.index)
+				record[i] = ""
+				if fieldErr == nil {
+					record[i] = csvValue(field)
+				}
+			}
+			err = writer.Write(record)
+		}
+		if err != nil {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("failed to export records", zap.Error(err), zap.Int("written", count))
+			c.Error(errors.New(err.Error()))
+			c.Abort()
+			return
+		}
+		count++
+		if count%csvFlushRows == 0 {
+			writer.Flush()
+			c.Writer.Flush()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		logger := utils.GetLoggerByCtx(c)
+		logger.Ctx(c).Error("failed to export records", zap.Error(err), zap.Int("written", count))
+		c.Error(errors.New(err.Error()))
+		c.Abort()
+		return
+	}
+	writer.Flush()
+}
@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/plugin/soft_delete"
+
+	"minigo/models"
+)
+
+// csvItem CSV 导出测试使用的模型
+type csvItem struct {
+	models.BaseModel
+	DeletedAt soft_delete.DeletedAt `json:"-"`
+	Name      string                `json:"name" ctags:"name,q,u"`
+}
+
+// progressRecorder 记录处理程序结束前已发送给客户端的字节数和 Flush 次数
+type progressRecorder struct {
+	*httptest.ResponseRecorder
+	done    *bool
+	early   int
+	flushes int
+}
+
+func (w *progressRecorder) Flush() {
+	if !*w.done {
+		w.flushes++
+	}
+	w.ResponseRecorder.Flush()
+}
+
+func (w *progressRecorder) Write(data []byte) (int, error) {
+	if !*w.done {
+		w.early += len(data)
+	}
+	return w.ResponseRecorder.Write(data)
+}
+
+func TestCSVExportStreamsRows(t *testing.T) {
+	db := openTestDataBase(t, csvItem{})
+	items := make([]csvItem, 3*csvFlushRows)
+	for i := range items {
+		items[i].Name = fmt.Sprintf("item-%03d", i)
+	}
+	if err := db.Create(&items).Error; err != nil {
+		t.Fatalf("failed to create records: %v", err)
+	}
+
+	for _, encoding := range []string{"", "gzip"} {
+		t.Run("encoding="+encoding, func(t *testing.T) {
+			done := false
+			r := newTestRouter(db, 0, func(c *gin.Context) {
+				c.Next()
+				done = true
+			})
+			path := registerModel(r, db, csvItem{})
+
+			req := httptest.NewRequest(http.MethodGet, path+"?format=csv&page_size=1000", nil)
+			if encoding != "" {
+				req.Header.Set("Accept-Encoding", encoding)
+			}
+			w := &progressRecorder{ResponseRecorder: httptest.NewRecorder(), done: &done}
+			r.ServeHTTP(w, req)
+
+			expectStatus(t, w.ResponseRecorder, http.StatusOK)
+			// 每 csvFlushRows 行发送一次数据
+			if w.early == 0 || w.flushes < 3 {
+				t.Fatalf("rows not streamed before the handler finished: %d bytes, %d flushes", w.early, w.flushes)
+			}
+
+			var body io.Reader = w.Body
+			if encoding == "gzip" {
+				if w.Header().Get("Content-Encoding") != "gzip" {
+					t.Fatalf("response not compressed: %v", w.Header())
+				}
+				reader, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatalf("invalid gzip body: %v", err)
+				}
+				body = reader
+			}
+			records, err := csv.NewReader(body).ReadAll()
+			if err != nil {
+				t.Fatalf("invalid csv: %v", err)
+			}
+			if len(records) != len(items)+1 {
+				t.Fatalf("got %d rows, want %d", len(records)-1, len(items))
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// 列表中软删除记录的展示方式
+const (
+	deletedExclude = ""     // 仅未删除的记录（默认）
+	deletedOnly    = "only" // 仅软删除的记录，用于回收站
+	deletedAll     = "all"  // 全部记录
+)
+
+// errNoSoftDelete 模型没有软删除字段
+var errNoSoftDelete = errors.New("resource does not support soft delete")
+
+// applyDeletedFilter 按 deleted 参数处理软删除记录，only 和 all 时取消软删除条件，only 时只保留已删除的记录
+func applyDeletedFilter(query *gorm.DB, mode string) (*gorm.DB, error) {
+	switch mode {
+	case deletedExclude:
+		return query, nil
+	case deletedAll:
+		return query.Unscoped(), nil
+	case deletedOnly:
+		if err := query.Statement.Parse(query.Statement.Model); err != nil {
+			return nil, err
+		}
+		// 软删除字段类型实现了 QueryClausesInterface，gorm.DeletedAt 以 NULL 表示未删除，soft_delete.DeletedAt 以 0 表示未删除
+		for _, field := range query.Statement.Schema.Fields {
+			if _, ok := reflect.New(field.IndirectFieldType).Interface().(schema.QueryClausesInterface); !ok {
+				continue
+			}
+			if field.FieldType == reflect.TypeOf(gorm.DeletedAt{}) {
+				return query.Unscoped().Where(fmt.Sprintf("%s IS NOT NULL", field.DBName)), nil
+			}
+			return query.Unscoped().Where(fmt.Sprintf("%s <> 0", field.DBName)), nil
+		}
+		return nil, errNoSoftDelete
+	default:
+		return nil, fmt.Errorf("invalid deleted mode: %s", mode)
+	}
+}
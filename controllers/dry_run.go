@@ -0,0 +1,24 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// isDryRun 判断请求是否为试运行，dry_run=true 时执行绑定、字段过滤和校验，但不写入数据库
+// 试运行时设置 tx_rollback，由事务中间件在请求结束时回滚事务，事务内校验等操作产生的修改不会提交
+func isDryRun(c *gin.Context) bool {
+	enabled, _ := strconv.ParseBool(c.Query("dry_run"))
+	if enabled {
+		c.Set("tx_rollback", true)
+	}
+	return enabled
+}
+
+// respondDryRun 返回试运行的结果，data 为将要写入的数据
+func respondDryRun(c *gin.Context, data gin.H) {
+	data["dry_run"] = true
+	c.JSON(http.StatusOK, data)
+}
@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	r, db := setupTest(t, chunkItem{})
+	path := "/api/chunk_items"
+	db.Create(&chunkItem{Name: "existing"})
+
+	// 创建：返回将要写入的记录，不写入数据库
+	w := request(r, http.MethodPost, path+"?dry_run=true", `[{"name":"a"},{"name":"b"}]`)
+	expectStatus(t, w, http.StatusOK)
+	body := decode(t, w)
+	if body["dry_run"] != true || len(body["data"].([]interface{})) != 2 {
+		t.Fatalf("dry run create response: %v", body)
+	}
+	// 校验失败时同样返回校验错误
+	w = request(r, http.MethodPost, path+"?dry_run=true", `{}`)
+	expectStatus(t, w, http.StatusUnprocessableEntity)
+
+	// 单一、批量和按条件更新：返回将要更新的字段，不修改记录
+	for _, tt := range []struct{ method, target, body string }{
+		{http.MethodPut, path + "/1?dry_run=true", `{"name":"renamed"}`},
+		{http.MethodPut, path + "?dry_run=true", `{"objs":[{"id":1,"name":"renamed"}]}`},
+		{http.MethodPatch, path + "?name=existing&dry_run=true", `{"name":"renamed"}`},
+	} {
+		w = request(r, tt.method, tt.target, tt.body)
+		expectStatus(t, w, http.StatusOK)
+		if body := decode(t, w); body["dry_run"] != true {
+			t.Fatalf("%s %s response: %v", tt.method, tt.target, body)
+		}
+	}
+
+	var names []string
+	db.Model(&chunkItem{}).Pluck("name", &names)
+	if len(names) != 1 || names[0] != "existing" {
+		t.Fatalf("records after dry run = %v, want [existing]", names)
+	}
+}
@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"minigo/utils"
+)
+
+// genericFilterUpdate 按查询条件批量更新，如 PATCH /api/user?status=pending 携带 {"status":"active"} 更新所有匹配的记录
+// 查询条件与列表相同（精确匹配和 _contains 模糊匹配），只能使用允许查询的字段，未知的条件返回 400 而不是忽略，
+// 没有任何条件时拒绝更新，避免误更新整张表；模型实现了 TxValidator 时逐条校验匹配的记录
+func genericFilterUpdate(c *gin.Context, db *gorm.DB, model interface{}, updates map[string]interface{}, allowedUpdateFields, writeOnceFields []string) {
+	modelType, modelPtr, _ := utils.GetModelInfo(model)
+
+	// 允许查询的字段，加密字段无法按明文匹配
+	var allowedQueryFields []string
+	for _, field := range utils.StructFields(modelType) {
+		tag := field.Tag.Get("ctags")
+		if tag != "" {
+			filedName := strings.Split(tag, ",")[0]
+			filedTags := strings.Split(tag, ",")[1:]
+			if filedName != "" && utils.ExistsIn(filedTags, "q") && !utils.ExistsIn(filedTags, "encrypt") {
+				allowedQueryFields = append(allowedQueryFields, filedName)
+			}
+		}
+	}
+
+	// 构建查询条件
+	query := db.Model(modelPtr)
+	filters := make(map[string]string)
+	for key, values := range c.Request.URL.Query() {
+		if key == "dry_run" {
+			continue
+		}
+		if !utils.ExistsIn(allowedQueryFields, strings.TrimSuffix(key, "_contains")) {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("invalid filter field", zap.String("field", key))
+			utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeValidationFailed, fmt.Sprintf("invalid filter field: %s", key), map[string]string{key: "not queryable"})
+			return
+		}
+
+		value := values[0]
+		if strings.HasSuffix(key, "_contains") {
+			query = query.Where(fmt.Sprintf("%s LIKE ?", strings.TrimSuffix(key, "_contains")), "%"+value+"%")
+		} else {
+			query = query.Where(fmt.Sprintf("%s = ?", key), value)
+		}
+		filters[key] = value
+	}
+	if len(filters) == 0 {
+		logger := utils.GetLoggerByCtx(c)
+		logger.Ctx(c).Error("filter update without filters")
+		utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeValidationFailed, "at least one filter is required", nil)
+		return
+	}
+
+	// 仅创建时可设置的字段不允许更新
+	if rejectWriteOnce(c, writeOnceFields, updates) {
+		return
+	}
+
+	// 仅允许更新特定字段
+	filteredUpdates := make(map[string]interface{})
+	for key, value := range updates {
+		if utils.ExistsIn(allowedUpdateFields, key) {
+			filteredUpdates[key] = value
+		}
+	}
+	if len(filteredUpdates) == 0 {
+		logger := utils.GetLoggerByCtx(c)
+		logger.Ctx(c).Error("no available fields to update")
+		utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeValidationFailed, "no available fields to update", nil)
+		return
+	}
+
+	// 事务内校验，逐条校验匹配的记录
+	if _, ok := modelPtr.(TxValidator); ok {
+		pkColumn, _ := primaryKeyOf(db.Model(modelPtr))
+		var ids []interface{}
+		if err := query.Session(&gorm.Session{}).Pluck(pkColumn, &ids).Error; err != nil {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("failed to query records", zap.Error(err))
+			c.Error(errors.New(err.Error()))
+			utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeDatabase, "failed to query records", nil)
+			return
+		}
+		for _, id := range ids {
+			if err := validateUpdateTx(db, model, id, filteredUpdates); err != nil {
+				logger := utils.GetLoggerByCtx(c)
+				logger.Ctx(c).Error("failed to validate record", zap.Error(err))
+				c.Error(errors.New(err.Error()))
+				utils.RespondFieldErrors(c, http.StatusUnprocessableEntity, err.Error(), bindFieldErrors(err))
+				return
+			}
+		}
+	}
+
+	// 试运行时返回匹配的记录数和将要更新的数据
+	if isDryRun(c) {
+		var count int64
+		if err := query.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("failed to count records", zap.Error(err))
+			c.Error(errors.New(err.Error()))
+			utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeDatabase, "failed to count records", nil)
+			return
+		}
+		respondDryRun(c, gin.H{"affected": count, "data": filteredUpdates})
+		return
+	}
+
+	// 加密敏感字段
+	if err := utils.EncryptUpdates(modelType, filteredUpdates); err != nil {
+		logger := utils.GetLoggerByCtx(c)
+		logger.Ctx(c).Error("failed to encrypt fields", zap.Error(err))
+		c.Error(errors.New(err.Error()))
+		utils.RespondError(c, http.StatusInternalServerError, utils.ErrCodeInternal, "failed to encrypt fields", nil)
+		return
+	}
+
+	// JSON 列的嵌套值序列化后再更新
+	if err := utils.MarshalJSONUpdates(modelType, filteredUpdates); err != nil {
+		logger := utils.GetLoggerByCtx(c)
+		logger.Ctx(c).Error("failed to marshal json fields", zap.Error(err))
+		c.Error(errors.New(err.Error()))
+		utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeInvalidBody, "failed to marshal json fields", nil)
+		return
+	}
+
+	logger := utils.GetLoggerByCtx(c)
+	logger.Ctx(c).Debug("filter update request",
+		zap.Any("filters", filters),
+		zap.Strings("fields", utils.GetMapKeys(filteredUpdates)),
+	)
+
+	// 计数器版本号随更新自增，按条件更新不做版本检查
+	query = versionLockOf(db.Model(modelPtr), modelType).apply(query, filteredUpdates, nil, false)
+
+	result := query.Updates(filteredUpdates)
+	if result.Error != nil {
+		logger := utils.GetLoggerByCtx(c)
+		logger.Ctx(c).Error("failed to update records", zap.Error(result.Error))
+		c.Error(errors.New(result.Error.Error()))
+		utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeDatabase, "failed to update records", nil)
+		return
+	}
+
+	reportStatements(c)
+	c.JSON(http.StatusOK, gin.H{"message": "filter update successful", "affected": result.RowsAffected})
+}
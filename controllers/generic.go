@@ -16,32 +16,73 @@ import (
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
+	"minigo/middlewares"
 	"minigo/utils"
 )
 
 // 通用路由注册函数
-func RegisterGenericRoutes(r *gin.Engine, resourceName string, model interface{}) {
+func RegisterGenericRoutes(r *gin.Engine, resourceName string, model interface{}, opts ...RouteOption) {
+	options := &ResourceOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
 	// 创建路由组
 	group := r.Group(resourceName)
 
+	// 资源日志级别，供处理程序和访问日志中间件读取
+	if options.LogLevel != "" {
+		group.Use(func(c *gin.Context) {
+			c.Set("log_level", options.LogLevel)
+			c.Next()
+		})
+	}
+
+	// 资源绑定的数据库，在该数据库上开启事务，替换全局事务中间件开启的事务
+	if options.Database != "" {
+		db, exists := utils.LookupDataBase(options.Database)
+		if !exists {
+			panic(fmt.Sprintf("database %s not registered", options.Database))
+		}
+		group.Use(middlewares.TransactionMiddleware(db.DB))
+	}
+
+	// 资源自定义的错误信息，由 utils.RespondError 读取
+	if len(options.ErrorMessages) > 0 {
+		group.Use(func(c *gin.Context) {
+			c.Set("error_messages", options.ErrorMessages)
+			c.Next()
+		})
+	}
+
 	// 列表查询
 	group.GET("", func(c *gin.Context) {
-		genericList(c, model)
+		genericList(c, model, options)
 	})
 
 	// 创建资源
 	group.POST("", func(c *gin.Context) {
-		genericCreate(c, model)
+		genericCreate(c, model, options)
 	})
 
 	// 批量删除
 	group.DELETE("", func(c *gin.Context) {
-		genericBatchDelete(c, model)
+		genericBatchDelete(c, model, options)
 	})
 
 	// 批量更新
 	group.PUT("", func(c *gin.Context) {
-		genericUpdate(c, model)
+		genericUpdate(c, model, options)
+	})
+
+	// 批量更新，与 PUT 相同，按查询条件批量更新时通常使用 PATCH
+	group.PATCH("", func(c *gin.Context) {
+		genericUpdate(c, model, options)
+	})
+
+	// 资源元信息，可查询、可排序、可更新的字段
+	group.GET("/_meta", func(c *gin.Context) {
+		genericMeta(c, model)
 	})
 
 	// 获取单个资源
@@ -51,41 +92,48 @@ func RegisterGenericRoutes(r *gin.Engine, resourceName string, model interface{}
 
 	// 删除单个资源
 	group.DELETE("/:id", func(c *gin.Context) {
-		genericDelete(c, model)
+		genericDelete(c, model, options)
 	})
 
 	// 更新单个资源
 	group.PUT("/:id", func(c *gin.Context) {
-		genericUpdate(c, model)
+		genericUpdate(c, model, options)
+	})
+
+	// 资源能力查询
+	group.OPTIONS("", func(c *gin.Context) {
+		genericOptions(c, model, collectionMethods)
+	})
+	group.OPTIONS("/:id", func(c *gin.Context) {
+		genericOptions(c, model, itemMethods)
 	})
 }
 
 // 通用列表查询
-func genericList(c *gin.Context, model interface{}) {
+func genericList(c *gin.Context, model interface{}, options *ResourceOptions) {
 	// 获取数据库实例（自动绑定到事务中）
 	db := utils.GetDbByCtx(c)
 
 	// 分页参数
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
-	const MaxPageSize = 10000
-	pageSize = min(pageSize, MaxPageSize)
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(options.pageSizeOrDefault())))
+	pageSize = min(pageSize, options.maxPageSizeOrDefault())
 	offset := (page - 1) * pageSize
 
-	// 获取模型反射类型和指针
-	modelType, modelPtr, tableName := utils.GetModelInfo(model)
+	// 获取模型反射类型和指针，表名按绑定数据库的命名策略解析，与计数器名称一致
+	modelType, modelPtr, _ := utils.GetModelInfo(model)
+	tableName := utils.TableNameOf(db, model)
 
 	// 使用反射检查字段标签，获取允许更新字段列表
 	var allowedQueryFields []string
 	var allowedOrderFields []string = []string{"id"}
 
-	for i := 0; i < modelType.NumField(); i++ {
-		field := modelType.Field(i)
+	for _, field := range utils.StructFields(modelType) {
 		tag := field.Tag.Get("ctags")
 		if tag != "" {
 			filedName := strings.Split(tag, ",")[0]
 			filedTags := strings.Split(tag, ",")[1:]
-			if filedName != "" && utils.ExistsIn(filedTags, "q") {
+			if filedName != "" && utils.ExistsIn(filedTags, "q") && !utils.ExistsIn(filedTags, "encrypt") {
 				allowedQueryFields = append(allowedQueryFields, filedName)
 			}
 			if filedName != "" && utils.ExistsIn(filedTags, "o") {
@@ -104,15 +152,46 @@ func genericList(c *gin.Context, model interface{}) {
 	// 是否使用计数器
 	useCounter := true
 
-	// 处理搜索参数
-	searchParam := c.DefaultQuery("search", "")
+	// 处理搜索参数，去除首尾空白，空值或仅包含空白时不添加搜索条件（search= 表示清除搜索，返回全部记录）
+	searchParam := strings.TrimSpace(c.DefaultQuery("search", ""))
 	if searchParam != "" {
+		// search_fields 限定搜索的字段，形如 search_fields=username,email，只能是允许查询的字段；未指定时搜索所有字符串字段
+		var searchFields []string
+		if param := c.Query("search_fields"); param != "" {
+			for _, name := range strings.Split(param, ",") {
+				name = strings.TrimSpace(name)
+				if !utils.ExistsIn(allowedQueryFields, name) {
+					logger := utils.GetLoggerByCtx(c)
+					logger.Ctx(c).Error("invalid search field", zap.String("field", name))
+					utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeValidationFailed, fmt.Sprintf("invalid search field: %s", name), map[string]string{"search_fields": "not queryable"})
+					return
+				}
+				searchFields = append(searchFields, name)
+			}
+		}
+
+		// search_mode 指定匹配方式：contains（默认）为包含匹配，prefix 为前缀匹配，不以通配符开头，可以使用索引
+		var pattern string
+		switch mode := c.DefaultQuery("search_mode", "contains"); mode {
+		case "contains":
+			pattern = "%" + searchParam + "%"
+		case "prefix":
+			pattern = searchParam + "%"
+		default:
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("invalid search mode", zap.String("mode", mode))
+			utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeValidationFailed, fmt.Sprintf("invalid search mode: %s", mode), map[string]string{"search_mode": "must be contains or prefix"})
+			return
+		}
+
 		// 获取所有字符串类型的字段
 		var orConditions []string
 		var args []interface{}
 
-		for i := 0; i < modelType.NumField(); i++ {
-			field := modelType.Field(i)
+		for _, field := range utils.StructFields(modelType) {
+			if searchFields != nil && !utils.ExistsIn(searchFields, strings.Split(field.Tag.Get("ctags"), ",")[0]) {
+				continue
+			}
 
 			// 只处理字符串类型的字段
 			if field.Type.Kind() == reflect.String {
@@ -128,13 +207,13 @@ func genericList(c *gin.Context, model interface{}) {
 					}
 				}
 				columnName = utils.Camel2Snake(columnName)
-				if columnName == "password" { // 排除password字段
+				if columnName == "password" || utils.IsEncryptedField(field) { // 排除password字段和加密字段
 					continue
 				}
 
 				orConditions = append(orConditions, fmt.Sprintf("%s LIKE ?", columnName))
-				// TODO: 避免左通配符使用,如果确实需要完整的全文搜索考虑es或者根据实际使用数据库设置全文索引
-				args = append(args, "%"+searchParam+"%")
+				// 包含匹配的左通配符无法使用索引，需要走索引时使用 search_mode=prefix；如果确实需要完整的全文搜索考虑es或者根据实际使用数据库设置全文索引
+				args = append(args, pattern)
 			}
 		}
 
@@ -147,11 +226,13 @@ func genericList(c *gin.Context, model interface{}) {
 
 	// 处理其他查询参数
 	queryParams := c.Request.URL.Query()
+	counterName := tableName
+	filterCount := 0
 	for key, values := range queryParams {
-		if key == "page" || key == "page_size" || key == "order" || key == "search" {
+		if key == "page" || key == "page_size" || key == "order" || key == "search" || key == "search_fields" || key == "search_mode" || key == "deleted" || key == "exact_count" || key == "cursor" || key == "snapshot" || key == "format" || key == "links" {
 			continue
 		}
-		if !utils.ExistsIn(allowedQueryFields, key) {
+		if !utils.ExistsIn(allowedQueryFields, strings.TrimSuffix(key, "_contains")) {
 			continue
 		}
 
@@ -163,13 +244,50 @@ func genericList(c *gin.Context, model interface{}) {
 			query = query.Where(fmt.Sprintf("%s LIKE ?", field), "%"+value+"%")
 		} else {
 			query = query.Where(fmt.Sprintf("%s = ?", key), value)
+			if utils.HasGroupCounter(tableName, key) {
+				counterName = utils.GroupCounterName(tableName, key, value)
+			}
+		}
+		filterCount++
+		useCounter = false
+	}
+
+	// 仅按单个分组计数列精确过滤时，使用对应的分组计数器
+	if searchParam == "" && filterCount == 1 && counterName != tableName {
+		useCounter = true
+	}
+
+	// 软删除记录，deleted=only 仅列出已删除的记录（回收站），deleted=all 列出全部记录，计数器只统计未删除的记录，此时直接统计
+	if deletedParam := c.Query("deleted"); deletedParam != deletedExclude {
+		var err error
+		if query, err = applyDeletedFilter(query, deletedParam); err != nil {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("invalid deleted filter", zap.Error(err))
+			reason := "must be only or all"
+			if errors.Is(err, errNoSoftDelete) {
+				reason = "not supported"
+			}
+			utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeValidationFailed, err.Error(), map[string]string{"deleted": reason})
+			return
 		}
 		useCounter = false
 	}
 
-	// 处理排序参数
-	orderParam := c.DefaultQuery("order", "-id")
-	if orderParam != "" && utils.ExistsIn(allowedOrderFields, strings.ReplaceAll(orderParam, "-", "")) {
+	// 处理排序参数（游标分页固定按主键排序，order 只决定升降序）
+	orderParam := c.DefaultQuery("order", options.orderOrDefault())
+	cursorToken, useCursor := c.GetQuery("cursor")
+	cursorDesc := strings.HasPrefix(orderParam, "-")
+	if useCursor {
+		// 游标只能按主键排序，显式指定其他排序字段时拒绝请求，避免静默返回按主键排序的结果
+		pkColumn, _ := primaryKeyOf(db.Model(modelPtr))
+		if param, ok := c.GetQuery("order"); ok && strings.TrimPrefix(param, "-") != pkColumn {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Warn("invalid cursor order", zap.String("order", param))
+			utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeValidationFailed, fmt.Sprintf("invalid order for cursor pagination: %s", param), map[string]string{"order": fmt.Sprintf("must be %s or -%s", pkColumn, pkColumn)})
+			return
+		}
+	}
+	if !useCursor && orderParam != "" && utils.ExistsIn(allowedOrderFields, strings.ReplaceAll(orderParam, "-", "")) {
 		// 判断是升序还是降序
 		var orderType string
 		var orderField string
@@ -189,168 +307,632 @@ func genericList(c *gin.Context, model interface{}) {
 		query = query.Order(orderQuery)
 	}
 
+	// 快照分页，携带 snapshot 参数时启用（空值表示创建新快照），翻页时传回响应中的 snapshot 令牌
+	snapshotToken, useSnapshot := c.GetQuery("snapshot")
+	if useSnapshot {
+		var err error
+		query, snapshotToken, err = applySnapshot(db, query, modelType, snapshotToken)
+		if err != nil {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("failed to apply snapshot", zap.Error(err))
+			c.Error(errors.New(err.Error()))
+			utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeBadRequest, "invalid snapshot", map[string]string{"snapshot": err.Error()})
+			return
+		}
+		useCounter = false
+	}
+
+	// exact_count=true 时忽略计数器，使用 COUNT(*) 统计总数
+	exactCount := false
+	if param := c.Query("exact_count"); param != "" {
+		var err error
+		if exactCount, err = strconv.ParseBool(param); err != nil {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("invalid exact_count", zap.Error(err))
+			utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeValidationFailed, fmt.Sprintf("invalid exact_count: %s", param), map[string]string{"exact_count": "must be a boolean"})
+			return
+		}
+	}
+
+	logger := utils.GetLoggerByCtx(c)
+	logger.Ctx(c).Debug("list request",
+		zap.String("query", c.Request.URL.RawQuery),
+		zap.Bool("use_counter", useCounter && !exactCount),
+		zap.Bool("use_cursor", useCursor),
+		zap.Bool("use_snapshot", useSnapshot),
+	)
+
+	// CSV 导出，format=csv 或 Accept: text/csv 时逐行输出匹配的记录，未指定 page_size 时导出全部记录
+	if wantsCSV(c) {
+		exportQuery := query
+		if c.Query("page_size") != "" {
+			exportQuery = query.Offset(offset).Limit(pageSize)
+		}
+		streamCSV(c, exportQuery, modelType, tableName)
+		return
+	}
+
 	// 大表统计直接从计数器表查询，如果查询失败则重新查询总数
+	// 功能开关 include_total 关闭时跳过统计
 	var total int64
-	if useCounter {
-		status := db.Raw("SELECT (counter) FROM counters WHERE name = ?", tableName).Scan(&total)
-		if status.Error != nil {
+	includeTotal := utils.FeatureEnabled(c, "include_total", true)
+	if includeTotal {
+		if options.IncludeDeleted {
+			// 计数器仅统计未删除的记录，包含软删除记录时直接统计
+			query.Session(&gorm.Session{}).Unscoped().Count(&total)
+		} else if useCounter && exactCount {
+			// 强制精确统计，同时读取计数器，不一致时记录日志并通过 X-Counter-Total 响应头返回计数器的值，用于排查计数器偏差
+			query.Count(&total)
+			if counter, err := utils.GetCounter(c, db, counterName); err == nil {
+				c.Header("X-Counter-Total", strconv.FormatInt(counter, 10))
+				if counter != total {
+					logger.Ctx(c).Warn("counter drift detected",
+						zap.String("counter", counterName),
+						zap.Int64("counter_total", counter),
+						zap.Int64("exact_total", total),
+					)
+				}
+			}
+		} else if useCounter {
+			counter, err := utils.GetCounter(c, db, counterName)
+			if err != nil {
+				query.Count(&total)
+			} else {
+				total = counter
+			}
+		} else {
 			query.Count(&total)
 		}
-	} else {
-		query.Count(&total)
+	}
+
+	// 游标分页，携带 cursor 参数时启用（空值表示第一页）
+	if useCursor {
+		data, nextCursor, prevCursor, err := cursorPaginate(query, modelType, pageSize, cursorToken, cursorDesc)
+		if err == nil {
+			err = utils.DecryptFields(data)
+		}
+		if err != nil {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("failed to query records", zap.Error(err))
+			utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeBadRequest, "invalid cursor or query", nil)
+			return
+		}
+		if !runAfterList(c, options, data) {
+			return
+		}
+
+		response := gin.H{
+			"page_size":   pageSize,
+			"data":        data,
+			"next_cursor": nextCursor,
+			"prev_cursor": prevCursor,
+		}
+		if includeTotal {
+			response["total"] = total
+		}
+		if useSnapshot {
+			response["snapshot"] = snapshotToken
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	// 预估结果占用的内存超过阈值时改为流式输出
+	if options.AfterList == nil && shouldStream(modelType, pageSize, options.StreamThreshold) {
+		logger.Ctx(c).Debug("stream list", zap.Int("page_size", pageSize))
+
+		meta := gin.H{
+			"page":      page,
+			"page_size": pageSize,
+		}
+		if includeTotal {
+			meta["total"] = total
+		}
+		if useSnapshot {
+			meta["snapshot"] = snapshotToken
+		}
+		if wantsLinks(c) {
+			meta["links"] = pageLinks(c, page, pageSize, total, includeTotal)
+		}
+		streamList(c, query.Offset(offset).Limit(pageSize), modelType, meta)
+		return
 	}
 
 	// 执行分页查询
 	err := query.Offset(offset).Limit(pageSize).Find(results.Addr().Interface()).Error
+	if err == nil {
+		err = utils.DecryptFields(results.Addr().Interface())
+	}
 	if err != nil {
-		logger := utils.GetLogger()
-		logger.WithTraceID(c.GetString("trace_id")).Error("failed to query records", zap.Error(err))
-		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		logger := utils.GetLoggerByCtx(c)
+		logger.Ctx(c).Error("failed to query records", zap.Error(err))
+		utils.RespondError(c, http.StatusNotFound, utils.ErrCodeNotFound, "failed to query records", nil)
+		return
+	}
+	if !runAfterList(c, options, results.Interface()) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"total":     total,
+	response := gin.H{
 		"page":      page,
 		"page_size": pageSize,
 		"data":      results.Interface(),
-	})
+	}
+	if includeTotal {
+		response["total"] = total
+	}
+	if useSnapshot {
+		response["snapshot"] = snapshotToken
+	}
+	if wantsLinks(c) {
+		response["links"] = pageLinks(c, page, pageSize, total, includeTotal)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// runAfterList 调用资源注册的列表结果处理钩子，钩子返回错误时响应 500 并返回 false
+func runAfterList(c *gin.Context, options *ResourceOptions, results interface{}) bool {
+	if options.AfterList == nil {
+		return true
+	}
+	if err := options.AfterList(c, results); err != nil {
+		logger := utils.GetLoggerByCtx(c)
+		logger.Ctx(c).Error("failed to process list results", zap.Error(err))
+		c.Error(errors.New(err.Error()))
+		utils.RespondError(c, http.StatusInternalServerError, utils.ErrCodeInternal, "failed to process records", nil)
+		return false
+	}
+	return true
 }
 
 // 通用资源创建
-func genericCreate(c *gin.Context, model interface{}) {
-	// 获取数据库实例（自动绑定到事务中）
-	db := utils.GetDbByCtx(c)
+func genericCreate(c *gin.Context, model interface{}, options *ResourceOptions) {
+	// 获取数据库实例（自动绑定到事务中），按功能开关统计 SQL 语句数
+	db := countStatements(c, utils.GetDbByCtx(c))
 
-	// 获取模型指针
-	_, modelPtr, _ := utils.GetModelInfo(model)
+	// 获取模型类型和指针，表名按绑定数据库的命名策略解析
+	modelType, modelPtr, _ := utils.GetModelInfo(model)
+	tableName := utils.TableNameOf(db, model)
 
 	// 解析请求数据
 	context, err := utils.UnbindContext(c)
 	if err != nil {
-		logger := utils.GetLogger()
-		logger.WithTraceID(c.GetString("trace_id")).Error("failed to parse context", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+		logger := utils.GetLoggerByCtx(c)
+		logger.Ctx(c).Error("failed to parse context", zap.Error(err))
+		if errors.Is(err, utils.ErrUnsupportedMediaType) {
+			utils.RespondError(c, http.StatusUnsupportedMediaType, utils.ErrCodeUnsupportedMediaType, "unsupported media type: "+c.ContentType(), nil)
+			return
+		}
+		utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeInvalidBody, "invalid request body", nil)
+		return
 	}
 
+	logger := utils.GetLoggerByCtx(c)
+	logger.Ctx(c).Debug("create request", zap.Int("count", len(context)))
+
+	// 试运行时只校验，不创建记录
+	dryRun := isDryRun(c)
+
+	// 幂等创建，携带 Idempotency-Key 时，有效期内重复的请求返回首次创建的记录，不再重复创建；试运行不使用幂等键
+	idempotencyKey := c.GetHeader(utils.IdempotencyKeyHeader)
+	if dryRun {
+		idempotencyKey = ""
+	}
+	var fingerprint string
+	if idempotencyKey != "" {
+		fingerprint = utils.RequestFingerprint(context)
+		ids, err := utils.LookupIdempotencyKey(db, tableName, idempotencyKey, fingerprint, options.idempotencyTTLOrDefault())
+		if err != nil {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("failed to lookup idempotency key", zap.Error(err))
+			c.Error(errors.New(err.Error()))
+			if errors.Is(err, utils.ErrIdempotencyKeyReused) {
+				utils.RespondError(c, http.StatusUnprocessableEntity, utils.ErrCodeConflict, err.Error(), nil)
+				return
+			}
+			utils.RespondError(c, http.StatusInternalServerError, utils.ErrCodeDatabase, "failed to lookup idempotency key", nil)
+			return
+		}
+		if len(ids) > 0 {
+			replayCreate(c, db, model, options, ids)
+			return
+		}
+	}
+
+	// 主键或唯一键冲突时的处理方式，on_conflict=ignore 保留已有记录，on_conflict=update 更新已有记录
+	conflict, err := parseConflictPolicy(db.Model(modelPtr), modelType, c.Query("on_conflict"), c.Query("conflict_columns"), c.Query("update_columns"))
+	if err != nil {
+		logger := utils.GetLoggerByCtx(c)
+		logger.Ctx(c).Error("invalid conflict policy", zap.Error(err))
+		utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeBadRequest, err.Error(), nil)
+		return
+	}
+
+	// 按配置分块提交，试运行时不提交
+	chunkSize := options.BatchChunkSize
+	if dryRun {
+		chunkSize = 0
+	}
+	chunker := newBatchChunker(chunkSize, len(context))
+
+	// 创建的记录及其主键，主键用于保存幂等键
+	_, pkField := primaryKeyOf(db.Model(modelPtr))
+	created := make([]interface{}, 0, len(context))
+	createdIDs := make([]interface{}, 0, len(context))
+
+	// 仅允许提交可创建的字段，客户端无法设置主键、时间戳等由服务端维护的字段
+	creatableFields := utils.CreatableFields(modelType)
+
 	for i := 0; i < len(context); i++ {
+		// 主键和时间戳始终由服务端生成，不受 ctags 影响
+		if stripped := utils.StripServerManagedFields(context[i]); len(stripped) > 0 {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Debug("ignored server managed fields", zap.Int("index", i), zap.Strings("fields", stripped))
+		}
+		if creatableFields != nil {
+			for key := range context[i] {
+				if !utils.ExistsIn(creatableFields, key) {
+					delete(context[i], key)
+				}
+			}
+		}
+
+		// 校验必填字段，缺失的字段与 validate 标签的校验错误合并返回
+		missing := utils.MissingRequiredFields(modelType, context[i])
+		fieldErrors := make([]utils.FieldError, 0, len(missing))
+		for _, name := range missing {
+			fieldErrors = append(fieldErrors, utils.FieldError{Field: name, Rule: "required"})
+		}
+
 		// 清空指针
 		_, modelPtr, _ = utils.GetModelInfo(model)
 
 		// 将 JSON 字节解析到模型指针
 		if err := utils.BindContext(context[i], modelPtr); err != nil {
-			logger := utils.GetLogger()
-			logger.WithTraceID(c.GetString("trace_id")).Error("failed to parse context", zap.Error(err))
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("failed to parse context", zap.Error(err))
+			c.Error(errors.New(err.Error()))
+			utils.RespondFieldErrors(c, http.StatusUnprocessableEntity, fmt.Sprintf("invalid object at index %d", i), bindFieldErrors(err))
+			return
+		}
+
+		// 按 validate 标签校验字段，已报告缺失的字段不重复报告
+		validationErrors, err := utils.ValidateStruct(modelPtr)
+		if err != nil {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("failed to validate struct", zap.Error(err))
+			c.Error(errors.New(err.Error()))
+			utils.RespondError(c, http.StatusInternalServerError, utils.ErrCodeInternal, "failed to validate record", nil)
+			return
+		}
+		for _, fieldError := range validationErrors {
+			if !utils.ExistsIn(missing, fieldError.Field) {
+				fieldErrors = append(fieldErrors, fieldError)
+			}
+		}
+		if len(fieldErrors) > 0 {
+			message := "invalid fields"
+			if len(missing) == len(fieldErrors) {
+				message = "missing required fields"
+			}
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error(message, zap.Any("fields", utils.FieldErrorsMap(fieldErrors)))
+			c.Error(errors.New(message))
+			utils.RespondFieldErrors(c, http.StatusUnprocessableEntity, message, fieldErrors)
+			return
+		}
+
+		// 事务内校验
+		if err := validateTx(db, modelPtr); err != nil {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("failed to validate record", zap.Error(err))
+			c.Error(errors.New(err.Error()))
+			utils.RespondError(c, http.StatusUnprocessableEntity, utils.ErrCodeValidationFailed, err.Error(), nil)
+			return
+		}
+
+		// 加密敏感字段
+		if err := utils.EncryptFields(modelPtr); err != nil {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("failed to encrypt fields", zap.Error(err))
 			c.Error(errors.New(err.Error()))
-			c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+			utils.RespondError(c, http.StatusInternalServerError, utils.ErrCodeInternal, "failed to encrypt fields", nil)
 			return
 		}
 
-		// 创建记录
-		if err := db.Create(modelPtr).Error; err != nil {
-			logger := utils.GetLogger()
-			logger.WithTraceID(c.GetString("trace_id")).Error("failed to create record", zap.Error(err))
+		// 创建记录，指定了冲突处理方式时冲突后重新读取实际记录；试运行时跳过
+		if !dryRun {
+			createDB := db
+			if conflict != nil {
+				createDB = db.Clauses(conflict.clause(modelType, context[i]))
+			}
+			err = createDB.Create(modelPtr).Error
+			if err == nil && conflict != nil {
+				err = conflict.reload(db, modelPtr)
+			}
+			if err != nil {
+				logger := utils.GetLoggerByCtx(c)
+				logger.Ctx(c).Error("failed to create record", zap.Error(err))
+				c.Error(errors.New(err.Error()))
+				utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeDatabase, "failed to create record", nil)
+				return
+			}
+
+			createdIDs = append(createdIDs, reflect.ValueOf(modelPtr).Elem().FieldByName(pkField).Interface())
+		}
+
+		// 解密敏感字段用于响应
+		if err := utils.DecryptFields(modelPtr); err != nil {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("failed to decrypt fields", zap.Error(err))
+			c.Error(errors.New(err.Error()))
+			utils.RespondError(c, http.StatusInternalServerError, utils.ErrCodeInternal, "failed to decrypt fields", nil)
+			return
+		}
+		created = append(created, modelPtr)
+
+		// 到达块边界时提交
+		if db, err = chunker.advance(c, db, i); err != nil {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("failed to commit chunk", zap.Error(err))
+			c.Error(errors.New(err.Error()))
+			utils.RespondError(c, http.StatusInternalServerError, utils.ErrCodeDatabase, "failed to commit chunk", nil)
+			return
+		}
+	}
+
+	// 保存幂等键，与最后创建的记录在同一事务中提交
+	if idempotencyKey != "" {
+		if err := utils.SaveIdempotencyKey(db, tableName, idempotencyKey, fingerprint, createdIDs); err != nil {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("failed to save idempotency key", zap.Error(err))
+			c.Error(errors.New(err.Error()))
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				utils.RespondError(c, http.StatusConflict, utils.ErrCodeConflict, "a request with the same idempotency key is in progress", nil)
+				return
+			}
+			utils.RespondError(c, http.StatusInternalServerError, utils.ErrCodeDatabase, "failed to save idempotency key", nil)
+			return
+		}
+	}
+
+	if dryRun {
+		respondDryRun(c, gin.H{"data": created})
+		return
+	}
+
+	chunker.finish(c)
+	reportStatements(c)
+	respondCreated(c, options, modelPtr, created, createdIDs)
+}
+
+// respondCreated 按配置的响应结构返回创建的记录，last 为最后创建的记录
+// 只创建了一条记录时通过 Location 响应头返回新记录的地址，批量创建多条时不返回
+func respondCreated(c *gin.Context, options *ResourceOptions, last interface{}, created []interface{}, ids []interface{}) {
+	if len(ids) == 1 {
+		c.Header("Location", strings.TrimSuffix(c.FullPath(), "/")+"/"+url.PathEscape(fmt.Sprint(ids[0])))
+	}
+
+	switch options.CreateResponse {
+	case CreateResponseArray:
+		c.JSON(http.StatusCreated, created)
+	case CreateResponseEnvelope:
+		c.JSON(http.StatusCreated, gin.H{"data": created, "total": len(created)})
+	default:
+		c.JSON(http.StatusCreated, last)
+	}
+}
+
+// replayCreate 重复的幂等创建请求，返回首次创建的记录，响应与首次创建一致并带有 Idempotent-Replayed 响应头
+func replayCreate(c *gin.Context, db *gorm.DB, model interface{}, options *ResourceOptions, ids []interface{}) {
+	_, modelPtr, _ := utils.GetModelInfo(model)
+	pkColumn, _ := primaryKeyOf(db.Model(modelPtr))
+
+	// 按创建顺序读取首次创建的记录
+	created := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		_, modelPtr, _ = utils.GetModelInfo(model)
+		err := db.Where(fmt.Sprintf("%s = ?", pkColumn), id).First(modelPtr).Error
+		if err == nil {
+			err = utils.DecryptFields(modelPtr)
+		}
+		if err != nil {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("failed to replay create", zap.Error(err))
 			c.Error(errors.New(err.Error()))
-			c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+			utils.RespondError(c, http.StatusInternalServerError, utils.ErrCodeDatabase, "failed to query record", nil)
 			return
 		}
+		created = append(created, modelPtr)
 	}
 
-	c.JSON(http.StatusCreated, modelPtr)
+	c.Header("Idempotent-Replayed", "true")
+	respondCreated(c, options, modelPtr, created, ids)
 }
 
 // 通用批量删除
-func genericBatchDelete(c *gin.Context, model interface{}) {
-	// 获取数据库实例（自动绑定到事务中）
-	db := utils.GetDbByCtx(c)
+func genericBatchDelete(c *gin.Context, model interface{}, options *ResourceOptions) {
+	// 获取数据库实例（自动绑定到事务中），按功能开关统计 SQL 语句数
+	db := countStatements(c, utils.GetDbByCtx(c))
 
-	var ids []int
+	// 获取模型类型和指针
+	modelType, modelPtr, _ := utils.GetModelInfo(model)
+
+	var rawIDs []interface{}
 
 	// 支持 JSON、Form 和 Query 参数
-	switch c.ContentType() {
-	case "application/json":
+	switch {
+	case utils.IsJSONMediaType(c.ContentType()):
 		// 解析 json 格式，形如 {"ids":[1, 2, 3, 4, 5, 6]}
+		// 元素也可以是对象，形如 {"ids":[1, {"id":2,"hard":true}]}，hard 为 true 时物理删除
 		var body map[string]interface{}
 		if err := c.ShouldBindJSON(&body); err != nil {
 			break
 		}
-		if body["ids"] != nil {
-			idsInterface := body["ids"].([]interface{})
-			ids = make([]int, len(idsInterface))
-			for i, v := range idsInterface {
-				ids[i] = int(v.(float64))
-			}
+		if idsInterface, ok := body["ids"].([]interface{}); ok {
+			rawIDs = idsInterface
 		}
 	default:
 		// 获取查询参数，形如 ?ids=1,2,3,4,5,6
 		idParams := c.Query("ids")
 		if idParams != "" {
 			// 使用 strings.Split 将参数按逗号分隔
-			idStrings := strings.Split(idParams, ",")
-
-			// 转换为整数切片
-			for _, idStr := range idStrings {
-				id, err := strconv.Atoi(idStr) // 字符串转换为整数
-				if err != nil {
-					logger := utils.GetLogger()
-					logger.WithTraceID(c.GetString("trace_id")).Error("failed to convert string to int", zap.Error(err))
-					c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
-					return
-				}
-				ids = append(ids, id)
+			for _, idStr := range strings.Split(idParams, ",") {
+				rawIDs = append(rawIDs, idStr)
 			}
 		} else {
 			// 如果没有，解析 form 格式，形如 ids=[1,2,3,4,5,6]
 			// gin默认不解析delete请求体，需要手动解析请求体中的表单数据
 			body, err := io.ReadAll(c.Request.Body)
 			if err != nil {
-				logger := utils.GetLogger()
-				logger.WithTraceID(c.GetString("trace_id")).Error("failed to read body", zap.Error(err))
-				c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+				logger := utils.GetLoggerByCtx(c)
+				logger.Ctx(c).Error("failed to read body", zap.Error(err))
+				utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeInvalidBody, "failed to read request body", nil)
 				return
 			}
 			values, err := url.ParseQuery(string(body))
 			if err != nil {
-				logger := utils.GetLogger()
-				logger.WithTraceID(c.GetString("trace_id")).Error("failed to parse form", zap.Error(err))
-				c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+				logger := utils.GetLoggerByCtx(c)
+				logger.Ctx(c).Error("failed to parse form", zap.Error(err))
+				utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeInvalidBody, "invalid form body", nil)
 				return
 			}
 			idStrings := values.Get("ids")
 			if idStrings == "" {
 				break
 			}
-			err = json.Unmarshal([]byte(idStrings), &ids)
+			err = json.Unmarshal([]byte(idStrings), &rawIDs)
 			if err != nil {
-				logger := utils.GetLogger()
-				logger.WithTraceID(c.GetString("trace_id")).Error("invalid ids format", zap.Error(err))
-				c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+				logger := utils.GetLoggerByCtx(c)
+				logger.Ctx(c).Error("invalid ids format", zap.Error(err))
+				utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeInvalidBody, "ids must be a JSON array", map[string]string{"ids": "invalid format"})
 				return
 			}
 		}
 	}
 
-	if len(ids) == 0 {
-		logger := utils.GetLogger()
-		logger.WithTraceID(c.GetString("trace_id")).Error("ids is empty")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+	if len(rawIDs) == 0 {
+		logger := utils.GetLoggerByCtx(c)
+		logger.Ctx(c).Error("ids is empty")
+		utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeValidationFailed, "ids is empty", map[string]string{"ids": "required"})
 		return
 	}
 
-	// 获取模型指针
-	_, modelPtr, _ := utils.GetModelInfo(model)
+	// 按模型主键类型转换，支持整数和字符串（如 UUID）主键，分为软删除和物理删除两组
+	ids := make([]interface{}, 0, len(rawIDs))
+	var softIDs, hardIDs []interface{}
+	for _, rawID := range rawIDs {
+		hard := false
+		if obj, ok := rawID.(map[string]interface{}); ok {
+			rawID = obj["id"]
+			hard, _ = obj["hard"].(bool)
+		}
+		id, err := utils.ParsePrimaryKey(modelType, rawID)
+		if err != nil {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("invalid ids format", zap.Error(err))
+			utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeValidationFailed, fmt.Sprintf("invalid id: %v", rawID), map[string]string{"ids": err.Error()})
+			return
+		}
+		ids = append(ids, id)
+		if hard {
+			hardIDs = append(hardIDs, id)
+		} else {
+			softIDs = append(softIDs, id)
+		}
+	}
 
-	// 批量删除
-	result := db.Delete(modelPtr, ids)
-	if result.Error != nil {
-		logger := utils.GetLogger()
-		logger.WithTraceID(c.GetString("trace_id")).Error("failed to delete records", zap.Error(result.Error))
-		c.Error(errors.New(result.Error.Error()))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+	logger := utils.GetLoggerByCtx(c)
+	logger.Ctx(c).Debug("batch delete request", zap.Any("ids", softIDs), zap.Any("hard_ids", hardIDs))
+
+	// 查找不存在的 ID，物理删除可以删除已软删除的记录，全部不存在时返回 404
+	notFoundIDs, err := missingIDs(db.Model(modelPtr), softIDs)
+	if err == nil {
+		var missingHard []interface{}
+		missingHard, err = missingIDs(db.Unscoped().Model(modelPtr), hardIDs)
+		notFoundIDs = append(notFoundIDs, missingHard...)
+	}
+	if err != nil {
+		logger.Ctx(c).Error("failed to query records", zap.Error(err))
+		c.Error(errors.New(err.Error()))
+		utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeDatabase, "failed to query records", nil)
+		return
+	}
+	if len(notFoundIDs) == len(ids) {
+		logger.Ctx(c).Error("records not found", zap.Any("ids", ids))
+		utils.RespondError(c, http.StatusNotFound, utils.ErrCodeNotFound, "records not found", map[string]string{"ids": "not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("deleted %d", result.RowsAffected)})
+	// 处理关联子记录
+	if err := applyDeleteRelations(db, options.relations, ids); err != nil {
+		logger.Ctx(c).Error("failed to apply delete relations", zap.Error(err))
+		c.Error(errors.New(err.Error()))
+		var conflict *relationConflictError
+		if errors.As(err, &conflict) {
+			utils.RespondError(c, http.StatusConflict, utils.ErrCodeConflict, conflict.Error(), nil)
+			return
+		}
+		utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeDatabase, "failed to delete related records", nil)
+		return
+	}
+
+	// 批量删除，软删除和物理删除在同一事务中执行，计数器由触发器维护
+	pkColumn, _ := primaryKeyOf(db.Model(modelPtr))
+	var affected int64
+	if len(softIDs) > 0 {
+		result := db.Where(fmt.Sprintf("%s IN ?", pkColumn), softIDs).Delete(modelPtr)
+		if result.Error != nil {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("failed to delete records", zap.Error(result.Error))
+			c.Error(errors.New(result.Error.Error()))
+			utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeDatabase, "failed to delete records", nil)
+			return
+		}
+		affected += result.RowsAffected
+	}
+	if len(hardIDs) > 0 {
+		result := db.Unscoped().Where(fmt.Sprintf("%s IN ?", pkColumn), hardIDs).Delete(modelPtr)
+		if result.Error != nil {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("failed to purge records", zap.Error(result.Error))
+			c.Error(errors.New(result.Error.Error()))
+			utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeDatabase, "failed to delete records", nil)
+			return
+		}
+		affected += result.RowsAffected
+	}
+
+	reportStatements(c)
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("deleted %d", affected), "not_found": notFoundIDs})
+}
+
+// missingIDs 查找不存在的 ID，主键按字符串比较，避免数据库驱动返回的整数类型与请求中的不一致
+func missingIDs(query *gorm.DB, ids []interface{}) ([]interface{}, error) {
+	missing := make([]interface{}, 0)
+	if len(ids) == 0 {
+		return missing, nil
+	}
+
+	pkColumn, _ := primaryKeyOf(query)
+	var existing []interface{}
+	if err := query.Where(fmt.Sprintf("%s IN ?", pkColumn), ids).Pluck(pkColumn, &existing).Error; err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]bool, len(existing))
+	for _, id := range existing {
+		if data, ok := id.([]byte); ok {
+			id = string(data)
+		}
+		found[fmt.Sprint(id)] = true
+	}
+	for _, id := range ids {
+		if !found[fmt.Sprint(id)] {
+			missing = append(missing, id)
+		}
+	}
+	return missing, nil
 }
 
 // 通用单个资源获取
@@ -358,21 +940,44 @@ func genericRetrieve(c *gin.Context, model interface{}) {
 	// 获取数据库实例（自动绑定到事务中）
 	db := utils.GetDbByCtx(c)
 
-	id := c.Param("id")
-
 	// 获取模型类型和指针
-	_, modelPtr, _ := utils.GetModelInfo(model)
+	modelType, modelPtr, _ := utils.GetModelInfo(model)
 
-	result := db.First(modelPtr, id)
+	id, err := utils.ParsePrimaryKey(modelType, c.Param("id"))
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, utils.ErrCodeNotFound, "record not found", nil)
+		return
+	}
+
+	pkColumn, _ := primaryKeyOf(db.Model(modelPtr))
+	result := db.Where(fmt.Sprintf("%s = ?", pkColumn), id).First(modelPtr)
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		utils.RespondError(c, http.StatusNotFound, utils.ErrCodeNotFound, "record not found", nil)
 		return
 	}
 
 	if result.Error != nil {
-		logger := utils.GetLogger()
-		logger.WithTraceID(c.GetString("trace_id")).Error("failed to query record", zap.Error(result.Error))
-		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		logger := utils.GetLoggerByCtx(c)
+		logger.Ctx(c).Error("failed to query record", zap.Error(result.Error))
+		utils.RespondError(c, http.StatusNotFound, utils.ErrCodeNotFound, "record not found", nil)
+		return
+	}
+
+	// 条件请求，If-None-Match 与记录的 ETag 匹配时返回 304，不返回记录内容
+	etag, err := recordETag(modelPtr)
+	if err == nil {
+		c.Header("ETag", etag)
+		if match := c.GetHeader("If-None-Match"); match != "" && etagMatches(match, etag) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	// 解密敏感字段
+	if err := utils.DecryptFields(modelPtr); err != nil {
+		logger := utils.GetLoggerByCtx(c)
+		logger.Ctx(c).Error("failed to decrypt fields", zap.Error(err))
+		utils.RespondError(c, http.StatusInternalServerError, utils.ErrCodeInternal, "failed to decrypt fields", nil)
 		return
 	}
 
@@ -380,58 +985,100 @@ func genericRetrieve(c *gin.Context, model interface{}) {
 }
 
 // 通用单个资源删除
-func genericDelete(c *gin.Context, model interface{}) {
+func genericDelete(c *gin.Context, model interface{}, options *ResourceOptions) {
 	// 获取数据库实例（自动绑定到事务中）
 	db := utils.GetDbByCtx(c)
 
-	id := c.Param("id")
-
 	// 获取模型类型和指针
-	_, modelPtr, _ := utils.GetModelInfo(model)
+	modelType, modelPtr, _ := utils.GetModelInfo(model)
+
+	id, err := utils.ParsePrimaryKey(modelType, c.Param("id"))
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, utils.ErrCodeNotFound, "record not found", nil)
+		return
+	}
 
-	// 设置ID
-	result := db.Delete(modelPtr, id)
+	// 处理关联子记录
+	if err := applyDeleteRelations(db, options.relations, []interface{}{id}); err != nil {
+		logger := utils.GetLoggerByCtx(c)
+		logger.Ctx(c).Error("failed to apply delete relations", zap.Error(err))
+		c.Error(errors.New(err.Error()))
+		var conflict *relationConflictError
+		if errors.As(err, &conflict) {
+			utils.RespondError(c, http.StatusConflict, utils.ErrCodeConflict, conflict.Error(), nil)
+			return
+		}
+		utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeDatabase, "failed to delete related records", nil)
+		return
+	}
+
+	// 按主键删除
+	pkColumn, _ := primaryKeyOf(db.Model(modelPtr))
+	result := db.Where(fmt.Sprintf("%s = ?", pkColumn), id).Delete(modelPtr)
 	if result.Error != nil {
-		logger := utils.GetLogger()
-		logger.WithTraceID(c.GetString("trace_id")).Error("failed to delete record", zap.Error(result.Error))
+		logger := utils.GetLoggerByCtx(c)
+		logger.Ctx(c).Error("failed to delete record", zap.Error(result.Error))
 		c.Error(errors.New(result.Error.Error()))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+		utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeDatabase, "failed to delete record", nil)
+		return
+	}
+	if result.RowsAffected == 0 {
+		// 记录不存在时回滚已处理的子记录
+		c.Error(errors.New("record not found"))
+		utils.RespondError(c, http.StatusNotFound, utils.ErrCodeNotFound, "record not found", nil)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("deleted %d", result.RowsAffected)})
+	c.JSON(http.StatusOK, gin.H{"message": "single delete successful", "affected": result.RowsAffected})
 }
 
 // 通用资源更新
-func genericUpdate(c *gin.Context, model interface{}) {
-	// 获取数据库实例（自动绑定到事务中）
-	db := utils.GetDbByCtx(c)
+func genericUpdate(c *gin.Context, model interface{}, options *ResourceOptions) {
+	// 获取数据库实例（自动绑定到事务中），按功能开关统计 SQL 语句数
+	db := countStatements(c, utils.GetDbByCtx(c))
 
-	// 使用反射检查字段标签，获取允许更新字段列表
+	// 使用反射检查字段标签，获取允许更新字段列表和仅创建时可设置的字段列表
 	var allowedUpdateFields []string
+	var writeOnceFields []string
 
 	// 获取模型反射类型和指针
 	modelType, modelPtr, _ := utils.GetModelInfo(model)
 
-	for i := 0; i < modelType.NumField(); i++ {
-		field := modelType.Field(i)
+	for _, field := range utils.StructFields(modelType) {
 		tag := field.Tag.Get("ctags")
 		if tag != "" {
 			filedName := strings.Split(tag, ",")[0]
 			filedTags := strings.Split(tag, ",")[1:]
+			if filedName != "" && utils.ExistsIn(filedTags, "wo") {
+				writeOnceFields = append(writeOnceFields, filedName)
+				continue
+			}
 			if filedName != "" && utils.ExistsIn(filedTags, "u") {
 				allowedUpdateFields = append(allowedUpdateFields, filedName)
 			}
 		}
 	}
 
+	// 试运行时只校验，返回将要更新的数据，不更新记录
+	dryRun := isDryRun(c)
+
 	// 判断URL路径中是否包含ID，来区分是批量更新还是单一更新
 	if urlPathID := c.Param("id"); urlPathID == "" {
+		// 携带查询条件且请求体不包含 objs 时，按条件批量更新，dry_run 不是查询条件
+		filters := c.Request.URL.Query()
+		filters.Del("dry_run")
+		if len(filters) > 0 {
+			if contexts, err := utils.UnbindContext(c); err == nil && len(contexts) == 1 && contexts[0]["objs"] == nil {
+				genericFilterUpdate(c, db, model, contexts[0], allowedUpdateFields, writeOnceFields)
+				return
+			}
+		}
+
 		// 处理批量更新
 		var objs []map[string]interface{}
 
 		// 解析 json 格式，形如 {"objs":[{},{}]}
-		if c.ContentType() == "application/json" {
+		if utils.IsJSONMediaType(c.ContentType()) {
 			var requestBody struct {
 				Objs []map[string]interface{} `json:"objs"`
 			}
@@ -442,43 +1089,69 @@ func genericUpdate(c *gin.Context, model interface{}) {
 			// 解析 form 格式，形如 objs=[{},{}]
 			body, err := io.ReadAll(c.Request.Body)
 			if err != nil {
-				logger := utils.GetLogger()
-				logger.WithTraceID(c.GetString("trace_id")).Error("failed to read body", zap.Error(err))
-				c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+				logger := utils.GetLoggerByCtx(c)
+				logger.Ctx(c).Error("failed to read body", zap.Error(err))
+				utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeInvalidBody, "failed to read request body", nil)
 				return
 			}
 			values, err := url.ParseQuery(string(body))
 			if err != nil {
-				logger := utils.GetLogger()
-				logger.WithTraceID(c.GetString("trace_id")).Error("failed to parse form", zap.Error(err))
-				c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+				logger := utils.GetLoggerByCtx(c)
+				logger.Ctx(c).Error("failed to parse form", zap.Error(err))
+				utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeInvalidBody, "invalid form body", nil)
 				return
 			}
 			objStrings := values.Get("objs")
 			err = json.Unmarshal([]byte(objStrings), &objs)
 			if err != nil {
-				logger := utils.GetLogger()
-				logger.WithTraceID(c.GetString("trace_id")).Error("invalid objs format", zap.Error(err))
-				c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+				logger := utils.GetLoggerByCtx(c)
+				logger.Ctx(c).Error("invalid objs format", zap.Error(err))
+				utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeInvalidBody, "objs must be a JSON array", map[string]string{"objs": "invalid format"})
 				return
 			}
 		}
 
 		if len(objs) == 0 {
-			logger := utils.GetLogger()
-			logger.WithTraceID(c.GetString("trace_id")).Error("objs is empty")
-			c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("objs is empty")
+			utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeValidationFailed, "objs is empty", map[string]string{"objs": "required"})
 			return
 		}
 
-		// 执行批量更新
-		for _, obj := range objs {
-			id, exists := obj["id"]
+		logger := utils.GetLoggerByCtx(c)
+		logger.Ctx(c).Debug("batch update request", zap.Int("count", len(objs)))
+
+		// 按配置分块提交
+		chunker := newBatchChunker(options.BatchChunkSize, len(objs))
+
+		// 乐观锁版本字段
+		lock := versionLockOf(db.Model(modelPtr), modelType)
+		pkColumn, _ := primaryKeyOf(db.Model(modelPtr))
+
+		// 执行批量更新，分别记录已更新和不存在的 ID
+		updatedIDs := make([]interface{}, 0, len(objs))
+		notFoundIDs := make([]interface{}, 0)
+		previews := make([]gin.H, 0)
+		for i, obj := range objs {
+			rawID, exists := obj["id"]
 			if !exists {
-				logger := utils.GetLogger()
-				logger.WithTraceID(c.GetString("trace_id")).Error("missing 'id' in object list")
+				logger := utils.GetLoggerByCtx(c)
+				logger.Ctx(c).Error("missing 'id' in object list")
 				c.Error(errors.New("missing 'id' in object list"))
-				c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+				utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeValidationFailed, "missing 'id' in object list", map[string]string{"id": "required"})
+				return
+			}
+			id, err := utils.ParsePrimaryKey(modelType, rawID)
+			if err != nil {
+				logger := utils.GetLoggerByCtx(c)
+				logger.Ctx(c).Error("invalid 'id' in object list", zap.Error(err))
+				c.Error(errors.New(err.Error()))
+				utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeValidationFailed, fmt.Sprintf("invalid id: %v", rawID), map[string]string{"id": err.Error()})
+				return
+			}
+
+			// 仅创建时可设置的字段不允许更新
+			if rejectWriteOnce(c, writeOnceFields, obj) {
 				return
 			}
 
@@ -490,36 +1163,121 @@ func genericUpdate(c *gin.Context, model interface{}) {
 				}
 			}
 			if len(filteredUpdates) == 0 {
-				logger := utils.GetLogger()
-				logger.WithTraceID(c.GetString("trace_id")).Error("no available fields to update")
+				logger := utils.GetLoggerByCtx(c)
+				logger.Ctx(c).Error("no available fields to update")
 				c.Error(errors.New("no available fields to update"))
-				c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+				utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeValidationFailed, "no available fields to update", nil)
+				return
+			}
+
+			// 事务内校验
+			if err := validateUpdateTx(db, model, id, filteredUpdates); err != nil {
+				logger := utils.GetLoggerByCtx(c)
+				logger.Ctx(c).Error("failed to validate record", zap.Error(err))
+				c.Error(errors.New(err.Error()))
+				utils.RespondFieldErrors(c, http.StatusUnprocessableEntity, err.Error(), bindFieldErrors(err))
+				return
+			}
+
+			// 试运行时只记录将要更新的数据
+			if dryRun {
+				if recordExists(db, modelPtr, id) {
+					previews = append(previews, gin.H{"id": id, "updates": filteredUpdates})
+				} else {
+					notFoundIDs = append(notFoundIDs, id)
+				}
+				continue
+			}
+
+			// 加密敏感字段
+			if err := utils.EncryptUpdates(modelType, filteredUpdates); err != nil {
+				logger := utils.GetLoggerByCtx(c)
+				logger.Ctx(c).Error("failed to encrypt fields", zap.Error(err))
+				c.Error(errors.New(err.Error()))
+				utils.RespondError(c, http.StatusInternalServerError, utils.ErrCodeInternal, "failed to encrypt fields", nil)
+				return
+			}
+
+			// JSON 列的嵌套值序列化后再更新
+			if err := utils.MarshalJSONUpdates(modelType, filteredUpdates); err != nil {
+				logger := utils.GetLoggerByCtx(c)
+				logger.Ctx(c).Error("failed to marshal json fields", zap.Error(err))
+				c.Error(errors.New(err.Error()))
+				utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeInvalidBody, "failed to marshal json fields", nil)
+				return
+			}
+
+			// 携带版本号时仅在版本一致时更新
+			expected, versioned := lock.expected(obj)
+			query := lock.apply(db.Model(modelPtr).Where(fmt.Sprintf("%s = ?", pkColumn), id), filteredUpdates, expected, versioned)
+
+			result := query.Updates(filteredUpdates)
+			if result.Error != nil {
+				logger := utils.GetLoggerByCtx(c)
+				logger.Ctx(c).Error("failed to update record", zap.Error(result.Error))
+				c.Error(errors.New(result.Error.Error()))
+				utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeDatabase, "failed to update record", nil)
+				return
+			}
+			if result.RowsAffected == 0 && versioned && recordExists(db, modelPtr, id) {
+				message := fmt.Sprintf("record %v has been modified", id)
+				logger := utils.GetLoggerByCtx(c)
+				logger.Ctx(c).Error("version conflict", zap.Any("id", id), zap.Any("version", expected))
+				c.Error(errors.New(message))
+				utils.RespondError(c, http.StatusConflict, utils.ErrCodeConflict, message, map[string]string{lock.name: "stale"})
 				return
 			}
+			if result.RowsAffected == 0 {
+				notFoundIDs = append(notFoundIDs, id)
+			} else {
+				updatedIDs = append(updatedIDs, id)
+			}
 
-			if err := db.Model(modelPtr).Where("id = ?", id).Updates(filteredUpdates).Error; err != nil {
-				logger := utils.GetLogger()
-				logger.WithTraceID(c.GetString("trace_id")).Error("failed to update record", zap.Error(err))
+			// 到达块边界时提交
+			if db, err = chunker.advance(c, db, i); err != nil {
+				logger := utils.GetLoggerByCtx(c)
+				logger.Ctx(c).Error("failed to commit chunk", zap.Error(err))
 				c.Error(errors.New(err.Error()))
-				c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+				utils.RespondError(c, http.StatusInternalServerError, utils.ErrCodeDatabase, "failed to commit chunk", nil)
 				return
 			}
 		}
 
-		c.JSON(http.StatusOK, gin.H{"message": "batch update successful"})
+		if dryRun {
+			respondDryRun(c, gin.H{"data": previews, "not_found": notFoundIDs})
+			return
+		}
+
+		chunker.finish(c)
+		reportStatements(c)
+		c.JSON(http.StatusOK, gin.H{"message": "batch update successful", "updated": updatedIDs, "not_found": notFoundIDs})
 	} else {
-		// 处理单一更新
-		id := c.Param("id") // 获取路径中的 ID
+		// 处理单一更新，按模型主键类型转换路径中的 ID
+		id, err := utils.ParsePrimaryKey(modelType, urlPathID)
+		if err != nil {
+			utils.RespondError(c, http.StatusNotFound, utils.ErrCodeNotFound, "record not found", nil)
+			return
+		}
 		contexts, err := utils.UnbindContext(c)
 		if err != nil {
-			logger := utils.GetLogger()
-			logger.WithTraceID(c.GetString("trace_id")).Error("failed to parse context", zap.Error(err))
-			c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("failed to parse context", zap.Error(err))
+			if errors.Is(err, utils.ErrUnsupportedMediaType) {
+				utils.RespondError(c, http.StatusUnsupportedMediaType, utils.ErrCodeUnsupportedMediaType, "unsupported media type: "+c.ContentType(), nil)
+				return
+			}
+			utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeInvalidBody, "invalid request body", nil)
+			return
 		}
 		if len(contexts) != 1 {
-			logger := utils.GetLogger()
-			logger.WithTraceID(c.GetString("trace_id")).Error("invalid request body")
-			c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("invalid request body")
+			utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeInvalidBody, "request body must be a single object", nil)
+			return
+		}
+
+		// 仅创建时可设置的字段不允许更新
+		if rejectWriteOnce(c, writeOnceFields, contexts[0]) {
 			return
 		}
 
@@ -531,21 +1289,110 @@ func genericUpdate(c *gin.Context, model interface{}) {
 			}
 		}
 		if len(filteredUpdates) == 0 {
-			logger := utils.GetLogger()
-			logger.WithTraceID(c.GetString("trace_id")).Error("no available fields to update")
-			c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("no available fields to update")
+			utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeValidationFailed, "no available fields to update", nil)
 			return
 		}
 
-		// 执行单一更新
-		if err := db.Model(modelPtr).Where("id = ?", id).Updates(filteredUpdates).Error; err != nil {
-			logger := utils.GetLogger()
-			logger.WithTraceID(c.GetString("trace_id")).Error("failed to update record", zap.Error(err))
+		// 事务内校验
+		if err := validateUpdateTx(db, model, id, filteredUpdates); err != nil {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("failed to validate record", zap.Error(err))
 			c.Error(errors.New(err.Error()))
-			c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+			utils.RespondFieldErrors(c, http.StatusUnprocessableEntity, err.Error(), bindFieldErrors(err))
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"message": "single update successful"})
+		// 试运行时返回将要更新的数据
+		if dryRun {
+			if !recordExists(db, modelPtr, id) {
+				utils.RespondError(c, http.StatusNotFound, utils.ErrCodeNotFound, "record not found", nil)
+				return
+			}
+			respondDryRun(c, gin.H{"data": gin.H{"id": id, "updates": filteredUpdates}})
+			return
+		}
+
+		// 加密敏感字段
+		if err := utils.EncryptUpdates(modelType, filteredUpdates); err != nil {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("failed to encrypt fields", zap.Error(err))
+			c.Error(errors.New(err.Error()))
+			utils.RespondError(c, http.StatusInternalServerError, utils.ErrCodeInternal, "failed to encrypt fields", nil)
+			return
+		}
+
+		// JSON 列的嵌套值序列化后再更新
+		if err := utils.MarshalJSONUpdates(modelType, filteredUpdates); err != nil {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("failed to marshal json fields", zap.Error(err))
+			c.Error(errors.New(err.Error()))
+			utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeInvalidBody, "failed to marshal json fields", nil)
+			return
+		}
+
+		logger := utils.GetLoggerByCtx(c)
+		logger.Ctx(c).Debug("single update request",
+			zap.Any("id", id),
+			zap.Strings("fields", utils.GetMapKeys(filteredUpdates)),
+		)
+
+		// 执行单一更新，携带版本号时仅在版本一致时更新
+		lock := versionLockOf(db.Model(modelPtr), modelType)
+		pkColumn, _ := primaryKeyOf(db.Model(modelPtr))
+		expected, versioned := lock.expected(contexts[0])
+		query := lock.apply(db.Model(modelPtr).Where(fmt.Sprintf("%s = ?", pkColumn), id), filteredUpdates, expected, versioned)
+
+		result := query.Updates(filteredUpdates)
+		if result.Error != nil {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("failed to update record", zap.Error(result.Error))
+			c.Error(errors.New(result.Error.Error()))
+			utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeDatabase, "failed to update record", nil)
+			return
+		}
+		if result.RowsAffected == 0 && versioned && recordExists(db, modelPtr, id) {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("version conflict", zap.Any("id", id), zap.Any("version", expected))
+			c.Error(errors.New("record has been modified"))
+			utils.RespondError(c, http.StatusConflict, utils.ErrCodeConflict, "record has been modified", map[string]string{lock.name: "stale"})
+			return
+		}
+		if result.RowsAffected == 0 {
+			utils.RespondError(c, http.StatusNotFound, utils.ErrCodeNotFound, "record not found", nil)
+			return
+		}
+
+		reportStatements(c)
+		c.JSON(http.StatusOK, gin.H{"message": "single update successful", "affected": result.RowsAffected})
+	}
+}
+
+// bindFieldErrors 将字段值类型错误转换为字段校验错误，其他错误返回 nil
+func bindFieldErrors(err error) []utils.FieldError {
+	var bindErr *utils.BindError
+	if !errors.As(err, &bindErr) {
+		return nil
+	}
+	return []utils.FieldError{{Field: bindErr.Field, Rule: "type"}}
+}
+
+// rejectWriteOnce 更新数据包含仅创建时可设置的字段时返回 422，返回是否已拒绝
+func rejectWriteOnce(c *gin.Context, writeOnceFields []string, updates map[string]interface{}) bool {
+	fields := make(map[string]string)
+	for _, name := range writeOnceFields {
+		if _, exists := updates[name]; exists {
+			fields[name] = "write_once"
+		}
 	}
+	if len(fields) == 0 {
+		return false
+	}
+
+	logger := utils.GetLoggerByCtx(c)
+	logger.Ctx(c).Error("write-once fields in update", zap.Strings("fields", utils.GetMapKeys(fields)))
+	c.Error(errors.New("write-once fields in update"))
+	utils.RespondError(c, http.StatusUnprocessableEntity, utils.ErrCodeValidationFailed, "write-once fields cannot be updated", fields)
+	return true
 }
@@ -0,0 +1,671 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/soft_delete"
+
+	"minigo/middlewares"
+	"minigo/models"
+	"minigo/utils"
+)
+
+func TestCreateSingleObjectAndArray(t *testing.T) {
+	r, db := setupTest(t, csvItem{})
+	path := "/api/csv_items"
+
+	w := request(r, http.MethodPost, path, `{"name":"single"}`)
+	expectStatus(t, w, http.StatusCreated)
+	if body := decode(t, w); body["name"] != "single" {
+		t.Fatalf("single create response: %v", body)
+	}
+
+	w = request(r, http.MethodPost, path, `[{"name":"first"},{"name":"second"}]`)
+	expectStatus(t, w, http.StatusCreated)
+
+	var names []string
+	db.Model(&csvItem{}).Order("id").Pluck("name", &names)
+	if len(names) != 3 || names[0] != "single" || names[1] != "first" || names[2] != "second" {
+		t.Fatalf("created records = %v, want [single first second]", names)
+	}
+}
+
+// managedItem 直接声明主键、时间戳和软删除字段的模型，字段均可通过 JSON 绑定
+type managedItem struct {
+	ID        uint                  `json:"id" gorm:"primarykey"`
+	CreatedAt int64                 `json:"created_at" gorm:"autoCreateTime:milli"`
+	UpdatedAt int64                 `json:"updated_at" gorm:"autoUpdateTime:milli"`
+	DeletedAt soft_delete.DeletedAt `json:"deleted_at"`
+	Name      string                `json:"name" ctags:"name,q,u"`
+}
+
+func TestCreateIgnoresServerManagedFields(t *testing.T) {
+	r, db := setupTest(t, managedItem{})
+	path := "/api/managed_items"
+	forged := `"id":100,"created_at":1,"updated_at":1,"deleted_at":1`
+
+	w := request(r, http.MethodPost, path, `{"name":"single",`+forged+`}`)
+	expectStatus(t, w, http.StatusCreated)
+	w = request(r, http.MethodPost, path, `[{"name":"first",`+forged+`},{"name":"second",`+forged+`}]`)
+	expectStatus(t, w, http.StatusCreated)
+
+	var items []managedItem
+	db.Unscoped().Order("id").Find(&items)
+	if len(items) != 3 {
+		t.Fatalf("created %d records, want 3", len(items))
+	}
+	for i, item := range items {
+		if item.ID != uint(i+1) || item.CreatedAt == 1 || item.UpdatedAt == 1 || item.DeletedAt != 0 {
+			t.Errorf("record %s kept forged server managed fields: %+v", item.Name, item)
+		}
+	}
+}
+
+// secretItem 加密字段测试使用的模型
+type secretItem struct {
+	models.BaseModel
+	DeletedAt soft_delete.DeletedAt `json:"-"`
+	Name      string                `json:"name" ctags:"name,q,u"`
+	Token     string                `json:"token" ctags:"token,u,encrypt"`
+}
+
+// storedToken 读取数据库中保存的 token 列
+func storedToken(t *testing.T, db *utils.Database, id int) string {
+	t.Helper()
+	var token string
+	if err := db.Raw("SELECT token FROM secret_items WHERE id = ?", id).Scan(&token).Error; err != nil {
+		t.Fatalf("failed to read token: %v", err)
+	}
+	return token
+}
+
+func TestEncryptedFields(t *testing.T) {
+	fc, err := utils.NewAESGCMCipher([]byte("0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("failed to create cipher: %v", err)
+	}
+	utils.SetFieldCipher(fc)
+	t.Cleanup(func() { utils.SetFieldCipher(nil) })
+
+	r, db := setupTest(t, secretItem{})
+	path := "/api/secret_items"
+
+	// 数据库中保存密文，响应中返回明文
+	w := request(r, http.MethodPost, path, `{"name":"item","token":"plain-token"}`)
+	expectStatus(t, w, http.StatusCreated)
+	if body := decode(t, w); body["token"] != "plain-token" {
+		t.Fatalf("create response token = %v", body["token"])
+	}
+	if stored := storedToken(t, db, 1); stored == "plain-token" || !strings.HasPrefix(stored, "enc:") {
+		t.Fatalf("stored token = %q, want ciphertext", stored)
+	}
+
+	w = request(r, http.MethodGet, path+"/1", "")
+	expectStatus(t, w, http.StatusOK)
+	if body := decode(t, w); body["token"] != "plain-token" {
+		t.Fatalf("retrieve response token = %v", body["token"])
+	}
+
+	// 更新时同样加密
+	w = request(r, http.MethodPut, path+"/1", `{"token":"new-token"}`)
+	expectStatus(t, w, http.StatusOK)
+	if stored := storedToken(t, db, 1); !strings.HasPrefix(stored, "enc:") {
+		t.Fatalf("updated token = %q, want ciphertext", stored)
+	}
+
+	w = request(r, http.MethodGet, path, "")
+	expectStatus(t, w, http.StatusOK)
+	data := decode(t, w)["data"].([]interface{})
+	if len(data) != 1 || data[0].(map[string]interface{})["token"] != "new-token" {
+		t.Fatalf("list response: %v", data)
+	}
+
+	// 加密字段不参与搜索
+	w = request(r, http.MethodGet, path+"?search=new-token", "")
+	expectStatus(t, w, http.StatusOK)
+	if data := decode(t, w)["data"].([]interface{}); len(data) != 0 {
+		t.Fatalf("search matched encrypted field: %v", data)
+	}
+}
+
+func TestSingleUpdateAndDeleteAffected(t *testing.T) {
+	r, db := setupTest(t, csvItem{})
+	path := "/api/csv_items"
+	db.Create(&csvItem{Name: "item"})
+
+	w := request(r, http.MethodPut, path+"/1", `{"name":"renamed"}`)
+	expectStatus(t, w, http.StatusOK)
+	if body := decode(t, w); body["affected"] != float64(1) {
+		t.Fatalf("update response: %v", body)
+	}
+	w = request(r, http.MethodPut, path+"/2", `{"name":"missing"}`)
+	expectStatus(t, w, http.StatusNotFound)
+
+	w = request(r, http.MethodDelete, path+"/1", "")
+	expectStatus(t, w, http.StatusOK)
+	if body := decode(t, w); body["affected"] != float64(1) {
+		t.Fatalf("delete response: %v", body)
+	}
+	// 已删除的记录不再受影响
+	w = request(r, http.MethodDelete, path+"/1", "")
+	expectStatus(t, w, http.StatusNotFound)
+	w = request(r, http.MethodPut, path+"/1", `{"name":"again"}`)
+	expectStatus(t, w, http.StatusNotFound)
+}
+
+// accountItem 仅创建时可设置字段的测试模型，username 创建后不可修改
+type accountItem struct {
+	models.BaseModel
+	DeletedAt soft_delete.DeletedAt `json:"-"`
+	Username  string                `json:"username" ctags:"username,q,wo"`
+	Nickname  string                `json:"nickname" ctags:"nickname,q,u"`
+}
+
+func TestWriteOnceFields(t *testing.T) {
+	r, db := setupTest(t, accountItem{})
+	path := "/api/account_items"
+
+	// 创建时接受
+	w := request(r, http.MethodPost, path, `{"username":"alice","nickname":"a"}`)
+	expectStatus(t, w, http.StatusCreated)
+	if body := decode(t, w); body["username"] != "alice" {
+		t.Fatalf("create response: %v", body)
+	}
+
+	// 单一、批量和按条件更新时拒绝
+	for _, tt := range []struct{ method, target, body string }{
+		{http.MethodPut, path + "/1", `{"username":"bob","nickname":"b"}`},
+		{http.MethodPut, path, `{"objs":[{"id":1,"username":"bob"}]}`},
+		{http.MethodPatch, path + "?username=alice", `{"username":"bob"}`},
+	} {
+		w = request(r, tt.method, tt.target, tt.body)
+		expectStatus(t, w, http.StatusUnprocessableEntity)
+		if fields, _ := decode(t, w)["error"].(map[string]interface{})["fields"].(map[string]interface{}); fields["username"] != "write_once" {
+			t.Fatalf("%s %s error fields: %v", tt.method, tt.target, fields)
+		}
+	}
+
+	// 不包含仅创建字段的更新正常执行
+	w = request(r, http.MethodPut, path+"/1", `{"nickname":"b"}`)
+	expectStatus(t, w, http.StatusOK)
+
+	var item accountItem
+	db.First(&item, 1)
+	if item.Username != "alice" || item.Nickname != "b" {
+		t.Fatalf("record = %+v", item)
+	}
+}
+
+// profileItem 字段校验错误测试使用的模型
+type profileItem struct {
+	models.BaseModel
+	DeletedAt soft_delete.DeletedAt `json:"-"`
+	Name      string                `json:"name" ctags:"name,q,u,required"`
+	Status    string                `json:"status" ctags:"status,q,u" validate:"omitempty,oneof=active inactive"`
+	Password  string                `json:"password" ctags:"password,u" validate:"omitempty,max=4"`
+}
+
+func TestFieldErrorDetails(t *testing.T) {
+	r, _ := setupTest(t, profileItem{})
+
+	w := request(r, http.MethodPost, "/api/profile_items", `{"status":"unknown","password":"secret"}`)
+	expectStatus(t, w, http.StatusUnprocessableEntity)
+	body := decode(t, w)["error"].(map[string]interface{})
+
+	details := make(map[string]map[string]interface{})
+	for _, item := range body["details"].([]interface{}) {
+		detail := item.(map[string]interface{})
+		details[detail["field"].(string)] = detail
+	}
+	want := map[string]map[string]interface{}{
+		"name":     {"field": "name", "rule": "required"},
+		"status":   {"field": "status", "rule": "oneof", "param": "active inactive", "value": "unknown"},
+		"password": {"field": "password", "rule": "max", "param": "4", "value": "[REDACTED]"},
+	}
+	if !reflect.DeepEqual(details, want) {
+		t.Fatalf("details = %v, want %v", details, want)
+	}
+	if fields := body["fields"].(map[string]interface{}); fields["name"] != "required" || fields["status"] != "oneof=active inactive" {
+		t.Fatalf("fields = %v", fields)
+	}
+}
+
+// credentialItem 只写字段测试使用的模型，secret 不参与序列化，按 ctags 字段名提交
+type credentialItem struct {
+	models.BaseModel
+	DeletedAt soft_delete.DeletedAt `json:"-"`
+	Name      string                `json:"name" ctags:"name,q,u"`
+	Secret    string                `json:"-" ctags:"secret,u"`
+}
+
+func TestWriteOnlyFieldBinding(t *testing.T) {
+	r, db := setupTest(t, credentialItem{})
+	path := "/api/credential_items"
+
+	w := request(r, http.MethodPost, path, `{"name":"item","secret":"created"}`)
+	expectStatus(t, w, http.StatusCreated)
+	if body := decode(t, w); body["secret"] != nil {
+		t.Fatalf("create response exposes write-only field: %v", body)
+	}
+	var item credentialItem
+	db.First(&item, 1)
+	if item.Secret != "created" {
+		t.Fatalf("stored secret = %q, want created", item.Secret)
+	}
+
+	w = request(r, http.MethodPut, path+"/1", `{"secret":"updated"}`)
+	expectStatus(t, w, http.StatusOK)
+	db.First(&item, 1)
+	if item.Secret != "updated" {
+		t.Fatalf("stored secret = %q, want updated", item.Secret)
+	}
+}
+
+func TestCreateUserWithPassword(t *testing.T) {
+	r, db := setupTest(t, models.User{})
+
+	// 密码字段 json:"-"，声明了 c 时按 ctags 字段名创建
+	w := request(r, http.MethodPost, "/api/users", `{"username":"alice","email":"alice@example.com","password":"secret"}`)
+	expectStatus(t, w, http.StatusCreated)
+	if body := decode(t, w); body["password"] != nil {
+		t.Fatalf("create response exposes password: %v", body)
+	}
+
+	var user models.User
+	db.First(&user, 1)
+	if user.Username != "alice" || user.Password != "secret" {
+		t.Fatalf("stored user = %+v", user)
+	}
+}
+
+func TestBatchDeleteMixedHardAndSoft(t *testing.T) {
+	r, db := setupTest(t, csvItem{})
+	path := "/api/csv_items"
+	for _, name := range []string{"a", "b", "c"} {
+		w := request(r, http.MethodPost, path, `{"name":"`+name+`"}`)
+		expectStatus(t, w, http.StatusCreated)
+	}
+
+	w := request(r, http.MethodDelete, path, `{"ids":[1,{"id":2,"hard":true}]}`)
+	expectStatus(t, w, http.StatusOK)
+
+	// id 1 软删除，id 2 物理删除
+	var items []csvItem
+	db.Unscoped().Order("id").Find(&items)
+	if len(items) != 2 || items[0].ID != 1 || items[0].DeletedAt == 0 || items[1].ID != 3 || items[1].DeletedAt != 0 {
+		t.Fatalf("records after delete = %+v", items)
+	}
+
+	var counter int64
+	db.Raw("SELECT counter FROM counters WHERE name = ?", "csv_items").Scan(&counter)
+	if counter != 1 {
+		t.Fatalf("counter = %d, want 1", counter)
+	}
+	w = request(r, http.MethodGet, path, "")
+	expectStatus(t, w, http.StatusOK)
+	if total := decode(t, w)["total"]; total != float64(1) {
+		t.Fatalf("list total = %v, want 1", total)
+	}
+}
+
+func TestListIncludeTotalFeature(t *testing.T) {
+	db := openTestDataBase(t, csvItem{})
+	r := newTestRouter(db, 0, middlewares.FeatureFlagMiddleware(map[string]bool{"include_total": true}))
+	path := registerModel(r, db, csvItem{})
+	db.Create(&csvItem{Name: "item"})
+
+	w := request(r, http.MethodGet, path, "")
+	expectStatus(t, w, http.StatusOK)
+	if total, exists := decode(t, w)["total"]; !exists || total != float64(1) {
+		t.Fatalf("default response total = %v", total)
+	}
+
+	// 网关通过请求头关闭统计总数
+	w = request(r, http.MethodGet, path, "", "X-Feature-Include-Total", "false")
+	expectStatus(t, w, http.StatusOK)
+	if total, exists := decode(t, w)["total"]; exists {
+		t.Fatalf("total should be omitted, got %v", total)
+	}
+}
+
+func TestCreateContentType(t *testing.T) {
+	r, _ := setupTest(t, csvItem{})
+
+	tests := []struct {
+		contentType string
+		status      int
+	}{
+		{"application/json; charset=utf-8", http.StatusCreated},
+		{"application/merge-patch+json", http.StatusCreated},
+		{"application/jsonx", http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		w := request(r, http.MethodPost, "/api/csv_items", `{"name":"item"}`, "Content-Type", tt.contentType)
+		if w.Code != tt.status {
+			t.Fatalf("%q: status = %d, want %d: %s", tt.contentType, w.Code, tt.status, w.Body.String())
+		}
+		if tt.status == http.StatusUnsupportedMediaType && !strings.Contains(w.Body.String(), utils.ErrCodeUnsupportedMediaType) {
+			t.Fatalf("%q: unexpected error body %s", tt.contentType, w.Body.String())
+		}
+	}
+}
+
+func TestListReadsCounterOnce(t *testing.T) {
+	r, db := setupTest(t, csvItem{})
+	for i := 0; i < 3; i++ {
+		db.Create(&csvItem{Name: "item"})
+	}
+
+	// 统计读取计数器表的语句数
+	reads := 0
+	countReads := func(tx *gorm.DB) {
+		if strings.Contains(tx.Statement.SQL.String(), "FROM counters") {
+			reads++
+		}
+	}
+	db.Callback().Query().After("gorm:query").Register("test:counter_reads", countReads)
+	db.Callback().Row().After("gorm:row").Register("test:counter_reads", countReads)
+
+	w := request(r, http.MethodGet, "/api/csv_items?page_size=2", "")
+	expectStatus(t, w, http.StatusOK)
+	if total := decode(t, w)["total"]; total != float64(3) {
+		t.Fatalf("list total = %v, want 3", total)
+	}
+	if reads != 1 {
+		t.Fatalf("counter reads = %d, want 1", reads)
+	}
+}
+
+func TestListTotalIncludesDeleted(t *testing.T) {
+	db := openTestDataBase(t, csvItem{}, chunkItem{})
+	r := newTestRouter(db, 0)
+	withDeleted := registerModel(r, db, csvItem{}, WithDeletedInTotal())
+	normal := registerModel(r, db, chunkItem{})
+	for _, name := range []string{"a", "b", "b"} {
+		db.Create(&csvItem{Name: name})
+		db.Create(&chunkItem{Name: name})
+	}
+	expectStatus(t, request(r, http.MethodDelete, withDeleted+"/2", ""), http.StatusOK)
+	expectStatus(t, request(r, http.MethodDelete, normal+"/2", ""), http.StatusOK)
+
+	tests := []struct {
+		path  string
+		query string
+		total float64
+		rows  int
+	}{
+		// 总数包含软删除的记录，返回的数据仍不包含
+		{withDeleted, "", 3, 2},
+		{withDeleted, "?name=b", 2, 1},
+		{normal, "", 2, 2},
+		{normal, "?name=b", 1, 1},
+	}
+	for _, tt := range tests {
+		w := request(r, http.MethodGet, tt.path+tt.query, "")
+		expectStatus(t, w, http.StatusOK)
+		body := decode(t, w)
+		if body["total"] != tt.total || len(body["data"].([]interface{})) != tt.rows {
+			t.Fatalf("%s%s: total = %v, rows = %d, want %v, %d", tt.path, tt.query, body["total"], len(body["data"].([]interface{})), tt.total, tt.rows)
+		}
+	}
+}
+
+func TestResourceErrorMessages(t *testing.T) {
+	db := openTestDataBase(t, csvItem{}, chunkItem{})
+	r := newTestRouter(db, 0)
+	custom := registerModel(r, db, csvItem{}, WithErrorMessage(http.StatusNotFound, "item not found"))
+	normal := registerModel(r, db, chunkItem{})
+
+	tests := []struct {
+		path    string
+		message string
+	}{
+		{custom + "/99", "item not found"},
+		{normal + "/99", "record not found"},
+	}
+	for _, tt := range tests {
+		w := request(r, http.MethodGet, tt.path, "")
+		expectStatus(t, w, http.StatusNotFound)
+		err := decode(t, w)["error"].(map[string]interface{})
+		if err["message"] != tt.message || err["code"] != utils.ErrCodeNotFound {
+			t.Fatalf("%s: error = %v, want message %q", tt.path, err, tt.message)
+		}
+	}
+}
+
+func TestBatchStatementCount(t *testing.T) {
+	db := openTestDataBase(t, csvItem{})
+	r := newTestRouter(db, 0, middlewares.FeatureFlagMiddleware(map[string]bool{"sql_stats": false}))
+	path := registerModel(r, db, csvItem{})
+	body := `[{"name":"a"},{"name":"b"},{"name":"c"}]`
+
+	// 批量创建逐条插入（事务内校验依赖之前插入的记录），每条记录一条 INSERT 语句
+	w := request(r, http.MethodPost, path, body, "X-Feature-Sql-Stats", "true")
+	expectStatus(t, w, http.StatusCreated)
+	if statements := w.Header().Get("X-SQL-Statements"); statements != "3" {
+		t.Fatalf("create X-SQL-Statements = %q, want 3", statements)
+	}
+
+	// 批量删除的语句数与记录数无关：查询存在的主键和一条 UPDATE 软删除语句
+	w = request(r, http.MethodDelete, path, `{"ids":[1,2,3]}`, "X-Feature-Sql-Stats", "true")
+	expectStatus(t, w, http.StatusOK)
+	if statements := w.Header().Get("X-SQL-Statements"); statements != "2" {
+		t.Fatalf("delete X-SQL-Statements = %q, want 2", statements)
+	}
+
+	// 未开启功能开关时不统计
+	w = request(r, http.MethodPost, path, body)
+	expectStatus(t, w, http.StatusCreated)
+	if statements := w.Header().Get("X-SQL-Statements"); statements != "" {
+		t.Fatalf("X-SQL-Statements = %q without sql_stats", statements)
+	}
+}
+
+func TestBatchUpdateReportsIDs(t *testing.T) {
+	r, db := setupTest(t, csvItem{})
+	path := "/api/csv_items"
+	for _, name := range []string{"a", "b", "c"} {
+		db.Create(&csvItem{Name: name})
+	}
+	db.Delete(&csvItem{}, 3)
+
+	// id 3 已软删除、id 9 不存在，均计入 not_found
+	w := request(r, http.MethodPut, path, `{"objs":[{"id":1,"name":"x"},{"id":9,"name":"y"},{"id":2,"name":"z"},{"id":3,"name":"w"}]}`)
+	expectStatus(t, w, http.StatusOK)
+	body := decode(t, w)
+	if !reflect.DeepEqual(body["updated"], []interface{}{float64(1), float64(2)}) || !reflect.DeepEqual(body["not_found"], []interface{}{float64(9), float64(3)}) {
+		t.Fatalf("batch update response = %v", body)
+	}
+
+	var items []csvItem
+	db.Order("id").Find(&items)
+	if len(items) != 2 || items[0].Name != "x" || items[1].Name != "z" {
+		t.Fatalf("records after update = %+v", items)
+	}
+
+	// 全部存在时 not_found 为空数组
+	w = request(r, http.MethodPut, path, `{"objs":[{"id":1,"name":"a"}]}`)
+	expectStatus(t, w, http.StatusOK)
+	if body := decode(t, w); !reflect.DeepEqual(body["not_found"], []interface{}{}) {
+		t.Fatalf("not_found = %#v, want empty array", body["not_found"])
+	}
+}
+
+func TestBlankSearchMatchesAll(t *testing.T) {
+	r, db := setupTest(t, csvItem{})
+	for _, name := range []string{"apple", "banana", "cherry"} {
+		db.Create(&csvItem{Name: name})
+	}
+
+	tests := []struct {
+		query string
+		total float64
+	}{
+		{"", 3},
+		{"?search=", 3},
+		{"?search=%20%20%20", 3},
+		{"?search=%09%0A", 3},
+		{"?search=%20banana%20", 1},
+	}
+	for _, tt := range tests {
+		w := request(r, http.MethodGet, "/api/csv_items"+tt.query, "")
+		expectStatus(t, w, http.StatusOK)
+		body := decode(t, w)
+		if body["total"] != tt.total || len(body["data"].([]interface{})) != int(tt.total) {
+			t.Fatalf("%q: total = %v, rows = %d, want %v", tt.query, body["total"], len(body["data"].([]interface{})), tt.total)
+		}
+	}
+}
+
+// pointerBaseItem 以指针嵌入 BaseModel 的模型
+type pointerBaseItem struct {
+	*models.BaseModel
+	DeletedAt soft_delete.DeletedAt `json:"-"`
+	Name      string                `json:"name" ctags:"name,q,u"`
+}
+
+func TestEmbeddedBaseModelPointer(t *testing.T) {
+	r, db := setupTest(t, pointerBaseItem{})
+	path := "/api/pointer_base_items"
+
+	// 嵌入指针中的主键和时间戳由 GORM 维护，请求中的值被忽略
+	w := request(r, http.MethodPost, path, `[{"name":"a","id":100,"created_at":1},{"name":"b"}]`)
+	expectStatus(t, w, http.StatusCreated)
+	body := decode(t, w)
+	if body["id"] != float64(2) || body["name"] != "b" || body["created_at"] == float64(0) {
+		t.Fatalf("create response = %v", body)
+	}
+	var first pointerBaseItem
+	if err := db.First(&first, 1).Error; err != nil || first.Name != "a" || first.CreatedAt == 1 {
+		t.Fatalf("first record = %+v, %v", first.BaseModel, err)
+	}
+
+	w = request(r, http.MethodGet, path+"?name=a", "")
+	expectStatus(t, w, http.StatusOK)
+	body = decode(t, w)
+	data := body["data"].([]interface{})
+	if body["total"] != float64(1) || len(data) != 1 {
+		t.Fatalf("list response = %v", body)
+	}
+	if item := data[0].(map[string]interface{}); item["id"] != float64(1) || item["name"] != "a" || item["created_at"] == float64(0) {
+		t.Fatalf("listed item = %v", item)
+	}
+
+	w = request(r, http.MethodPut, path+"/1", `{"name":"c"}`)
+	expectStatus(t, w, http.StatusOK)
+	w = request(r, http.MethodGet, path+"/1", "")
+	expectStatus(t, w, http.StatusOK)
+	if body := decode(t, w); body["name"] != "c" {
+		t.Fatalf("get after update = %v", body)
+	}
+}
+
+func TestExactCountBypassesCounter(t *testing.T) {
+	r, db := setupTest(t, csvItem{})
+	path := "/api/csv_items"
+	for _, name := range []string{"a", "b", "c"} {
+		db.Create(&csvItem{Name: name})
+	}
+	// 模拟计数器偏差
+	db.Exec("UPDATE counters SET counter = 42 WHERE name = ?", "csv_items")
+
+	w := request(r, http.MethodGet, path, "")
+	expectStatus(t, w, http.StatusOK)
+	if total := decode(t, w)["total"]; total != float64(42) {
+		t.Fatalf("counter total = %v, want 42", total)
+	}
+
+	// 精确统计忽略计数器，并通过响应头返回计数器的值
+	w = request(r, http.MethodGet, path+"?exact_count=true", "")
+	expectStatus(t, w, http.StatusOK)
+	if total := decode(t, w)["total"]; total != float64(3) {
+		t.Fatalf("exact total = %v, want 3", total)
+	}
+	if counter := w.Header().Get("X-Counter-Total"); counter != "42" {
+		t.Fatalf("X-Counter-Total = %q, want 42", counter)
+	}
+
+	w = request(r, http.MethodGet, path+"?exact_count=maybe", "")
+	expectStatus(t, w, http.StatusBadRequest)
+}
+
+func TestCreateResponseShape(t *testing.T) {
+	db := openTestDataBase(t, csvItem{})
+	r := newTestRouter(db, 0)
+	RegisterGenericRoutes(r, "/object", csvItem{})
+	RegisterGenericRoutes(r, "/array", csvItem{}, WithCreateResponse(CreateResponseArray))
+	RegisterGenericRoutes(r, "/envelope", csvItem{}, WithCreateResponse(CreateResponseEnvelope))
+
+	// names 从响应中按配置的结构取出创建的记录名称
+	names := func(shape string, body []byte) []string {
+		var records []map[string]interface{}
+		switch shape {
+		case "/object":
+			var record map[string]interface{}
+			if err := json.Unmarshal(body, &record); err != nil {
+				t.Fatalf("%s: invalid object response %s", shape, body)
+			}
+			records = append(records, record)
+		case "/array":
+			if err := json.Unmarshal(body, &records); err != nil {
+				t.Fatalf("%s: invalid array response %s", shape, body)
+			}
+		case "/envelope":
+			var envelope struct {
+				Data  []map[string]interface{} `json:"data"`
+				Total *int                     `json:"total"`
+			}
+			if err := json.Unmarshal(body, &envelope); err != nil || envelope.Total == nil || *envelope.Total != len(envelope.Data) {
+				t.Fatalf("%s: invalid envelope response %s", shape, body)
+			}
+			records = envelope.Data
+		}
+		var result []string
+		for _, record := range records {
+			result = append(result, record["name"].(string))
+		}
+		return result
+	}
+
+	tests := []struct {
+		shape  string
+		single []string
+		batch  []string
+	}{
+		// 默认结构批量创建时只返回最后一条
+		{"/object", []string{"a"}, []string{"c"}},
+		// 数组和列表结构单条与批量创建的结构一致
+		{"/array", []string{"a"}, []string{"b", "c"}},
+		{"/envelope", []string{"a"}, []string{"b", "c"}},
+	}
+	for _, tt := range tests {
+		w := request(r, http.MethodPost, tt.shape, `{"name":"a"}`)
+		expectStatus(t, w, http.StatusCreated)
+		if got := names(tt.shape, w.Body.Bytes()); !reflect.DeepEqual(got, tt.single) {
+			t.Fatalf("%s single create = %v, want %v", tt.shape, got, tt.single)
+		}
+		w = request(r, http.MethodPost, tt.shape, `[{"name":"b"},{"name":"c"}]`)
+		expectStatus(t, w, http.StatusCreated)
+		if got := names(tt.shape, w.Body.Bytes()); !reflect.DeepEqual(got, tt.batch) {
+			t.Fatalf("%s batch create = %v, want %v", tt.shape, got, tt.batch)
+		}
+
+		// 幂等重放的响应与首次创建的结构一致
+		key := "key" + tt.shape
+		w = request(r, http.MethodPost, tt.shape, `[{"name":"b"},{"name":"c"}]`, "Idempotency-Key", key)
+		expectStatus(t, w, http.StatusCreated)
+		first := w.Body.String()
+		w = request(r, http.MethodPost, tt.shape, `[{"name":"b"},{"name":"c"}]`, "Idempotency-Key", key)
+		if w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") == "" {
+			t.Fatalf("%s replay: %d %v", tt.shape, w.Code, w.Header())
+		}
+		if got := names(tt.shape, w.Body.Bytes()); !reflect.DeepEqual(got, tt.batch) {
+			t.Fatalf("%s replayed create = %v, want %v (first %s)", tt.shape, got, tt.batch, first)
+		}
+	}
+}
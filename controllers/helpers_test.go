@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"minigo/middlewares"
+	"minigo/utils"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// openTestDataBase 打开临时目录中的 SQLite 数据库并迁移模型
+func openTestDataBase(t *testing.T, models ...interface{}) *utils.Database {
+	t.Helper()
+	db, err := utils.OpenDataBase(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.DB = db.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Silent)})
+
+	if err := db.Migrate(models...); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return db
+}
+
+// newTestRouter 按 main.go 的顺序注册与数据库相关的中间件，retries 为死锁重试次数，leading 为注册在最前面的中间件
+func newTestRouter(db *utils.Database, retries int, leading ...gin.HandlerFunc) *gin.Engine {
+	r := gin.New()
+	r.Use(leading...)
+	r.Use(middlewares.GzipMiddleware(1024))
+	r.Use(middlewares.ResponseSizeLimitMiddleware(32 << 20))
+	r.Use(middlewares.DeadlockRetryMiddleware(retries))
+	r.Use(middlewares.TransactionMiddleware(db.DB))
+	return r
+}
+
+// registerModel 以表名注册模型的通用路由，返回资源路径
+func registerModel(r *gin.Engine, db *utils.Database, model interface{}, opts ...RouteOption) string {
+	modelType, _, _ := utils.GetModelInfo(model)
+	path := "/api/" + utils.TableNameOf(db.DB, model)
+	RegisterGenericRoutes(r, path, reflect.Zero(modelType).Interface(), opts...)
+	return path
+}
+
+// setupTest 创建数据库和路由并注册模型，返回路由和数据库
+func setupTest(t *testing.T, models ...interface{}) (*gin.Engine, *utils.Database) {
+	t.Helper()
+	db := openTestDataBase(t, models...)
+	r := newTestRouter(db, 0)
+	for _, model := range models {
+		registerModel(r, db, model)
+	}
+	return r, db
+}
+
+// request 执行请求，headers 为成对的请求头名称和值，有请求体时默认使用 JSON
+func request(r *gin.Engine, method, target, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// decode 解析 JSON 响应体
+func decode(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid json response %q: %v", w.Body.String(), err)
+	}
+	return body
+}
+
+// expectStatus 断言响应状态码
+func expectStatus(t *testing.T, w *httptest.ResponseRecorder, status int) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d, body: %s", w.Code, status, w.Body.String())
+	}
+}
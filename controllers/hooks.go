@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"minigo/utils"
+)
+
+// TxValidator 事务内校验钩子，模型实现该接口后，在创建和更新时使用请求事务调用
+// 校验可以查询到当前事务中未提交的数据，返回错误时请求事务回滚
+type TxValidator interface {
+	ValidateTx(tx *gorm.DB) error
+}
+
+// AfterListHook 列表查询后的结果处理钩子，通过 WithAfterList 按资源注册
+// results 为当前页的记录切片（如 []User），已解密敏感字段，可直接修改切片中的记录，如从外部服务批量补充数据；返回错误时响应 500
+type AfterListHook func(c *gin.Context, results interface{}) error
+
+// validateTx 调用模型的事务内校验钩子
+func validateTx(tx *gorm.DB, modelPtr interface{}) error {
+	if validator, ok := modelPtr.(TxValidator); ok {
+		return validator.ValidateTx(tx)
+	}
+	return nil
+}
+
+// validateUpdateTx 更新前调用事务内校验钩子，校验对象为当前记录合并更新字段后的结果
+func validateUpdateTx(tx *gorm.DB, model interface{}, id interface{}, updates map[string]interface{}) error {
+	_, modelPtr, _ := utils.GetModelInfo(model)
+	if _, ok := modelPtr.(TxValidator); !ok {
+		return nil
+	}
+
+	// 记录不存在时交由更新逻辑处理
+	pkColumn, _ := primaryKeyOf(tx.Model(modelPtr))
+	if err := tx.Where(fmt.Sprintf("%s = ?", pkColumn), id).First(modelPtr).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if err := utils.BindContext(updates, modelPtr); err != nil {
+		return err
+	}
+	return validateTx(tx, modelPtr)
+}
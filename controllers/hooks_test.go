@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/plugin/soft_delete"
+
+	"minigo/models"
+)
+
+// quotaItem 事务内校验测试使用的模型，同名记录最多 2 条，名称不能为 forbidden
+type quotaItem struct {
+	models.BaseModel
+	DeletedAt soft_delete.DeletedAt `json:"-"`
+	Name      string                `json:"name" ctags:"name,q,u"`
+}
+
+func (q *quotaItem) ValidateTx(tx *gorm.DB) error {
+	if q.Name == "forbidden" {
+		return errors.New("name is forbidden")
+	}
+	// 查询当前事务中的记录，包括同一请求中尚未提交的记录
+	var count int64
+	if err := tx.Model(&quotaItem{}).Where("name = ? AND id <> ?", q.Name, q.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count >= 2 {
+		return errors.New("too many items with the same name")
+	}
+	return nil
+}
+
+// countQuotaItems 统计指定名称的记录数
+func countQuotaItems(db *gorm.DB, name string) int64 {
+	var count int64
+	db.Model(&quotaItem{}).Where("name = ?", name).Count(&count)
+	return count
+}
+
+func TestValidateTx(t *testing.T) {
+	r, db := setupTest(t, quotaItem{})
+	path := "/api/quota_items"
+
+	// 第三条记录的校验能查询到同一事务中未提交的前两条记录，校验失败时整个请求回滚
+	w := request(r, http.MethodPost, path, `[{"name":"a"},{"name":"a"},{"name":"a"}]`)
+	expectStatus(t, w, http.StatusUnprocessableEntity)
+	if count := countQuotaItems(db.DB, "a"); count != 0 {
+		t.Fatalf("items = %d after rejected batch, want 0", count)
+	}
+
+	w = request(r, http.MethodPost, path, `[{"name":"a"},{"name":"a"}]`)
+	expectStatus(t, w, http.StatusCreated)
+	w = request(r, http.MethodPost, path, `{"name":"a"}`)
+	expectStatus(t, w, http.StatusUnprocessableEntity)
+	if count := countQuotaItems(db.DB, "a"); count != 2 {
+		t.Fatalf("items = %d, want 2", count)
+	}
+
+	// 更新时校验合并更新字段后的记录
+	w = request(r, http.MethodPut, path+"/1", `{"name":"forbidden"}`)
+	expectStatus(t, w, http.StatusUnprocessableEntity)
+	w = request(r, http.MethodPut, path+"/1", `{"name":"b"}`)
+	expectStatus(t, w, http.StatusOK)
+	if countQuotaItems(db.DB, "forbidden") != 0 || countQuotaItems(db.DB, "b") != 1 {
+		t.Fatalf("unexpected records after update")
+	}
+}
+
+func TestAfterListHook(t *testing.T) {
+	db := openTestDataBase(t, csvItem{})
+	r := newTestRouter(db, 0)
+	fail := false
+	path := registerModel(r, db, csvItem{}, WithStreamThreshold(1), WithAfterList(func(c *gin.Context, results interface{}) error {
+		if fail {
+			return errors.New("enrichment unavailable")
+		}
+		// 为当前页的记录补充数据
+		items := results.([]csvItem)
+		for i := range items {
+			items[i].Name += " (annotated)"
+		}
+		return nil
+	}))
+	for _, name := range []string{"a", "b", "c"} {
+		db.Create(&csvItem{Name: name})
+	}
+
+	// 偏移分页和游标分页均调用钩子，流式输出阈值不生效
+	for _, query := range []string{"?order=id", "?order=id&cursor="} {
+		w := request(r, http.MethodGet, path+query, "")
+		expectStatus(t, w, http.StatusOK)
+		var names []string
+		for _, item := range decode(t, w)["data"].([]interface{}) {
+			names = append(names, item.(map[string]interface{})["name"].(string))
+		}
+		if !reflect.DeepEqual(names, []string{"a (annotated)", "b (annotated)", "c (annotated)"}) {
+			t.Fatalf("%s: names = %v", query, names)
+		}
+	}
+
+	// 钩子只修改响应，不写回数据库
+	var item csvItem
+	db.First(&item, 1)
+	if item.Name != "a" {
+		t.Fatalf("stored name = %q, want a", item.Name)
+	}
+
+	// 钩子返回错误时响应 500
+	fail = true
+	w := request(r, http.MethodGet, path, "")
+	expectStatus(t, w, http.StatusInternalServerError)
+}
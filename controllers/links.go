@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// wantsLinks 判断列表是否返回分页链接，links=true 时返回
+func wantsLinks(c *gin.Context) bool {
+	enabled, _ := strconv.ParseBool(c.Query("links"))
+	return enabled
+}
+
+// pageLinks 生成 JSON:API 风格的分页链接，基于当前请求的路径和查询参数，只替换 page 和 page_size，保留过滤、排序等其他参数
+// 不存在的链接（如第一页的 prev）为 null；总数未知时（功能开关 include_total 关闭）不生成 last，next 始终生成
+func pageLinks(c *gin.Context, page, pageSize int, total int64, includeTotal bool) gin.H {
+	link := func(target int) *string {
+		query := c.Request.URL.Query()
+		query.Set("page", strconv.Itoa(target))
+		query.Set("page_size", strconv.Itoa(pageSize))
+		u := url.URL{Path: c.Request.URL.Path, RawQuery: query.Encode()}
+		s := u.String()
+		return &s
+	}
+
+	links := gin.H{"first": link(1), "prev": nil, "next": nil, "last": nil}
+	if page > 1 {
+		links["prev"] = link(page - 1)
+	}
+	if !includeTotal {
+		links["next"] = link(page + 1)
+		return links
+	}
+
+	lastPage := 1
+	if pageSize > 0 && total > 0 {
+		lastPage = int((total + int64(pageSize) - 1) / int64(pageSize))
+	}
+	links["last"] = link(lastPage)
+	if page < lastPage {
+		links["next"] = link(page + 1)
+	}
+	return links
+}
@@ -0,0 +1,146 @@
+package controllers
+
+import "time"
+
+// RouteOption 资源路由注册选项
+type RouteOption func(*ResourceOptions)
+
+// 资源配置的默认值
+const (
+	defaultPageSize = 10    // 默认每页记录数
+	maxPageSize     = 10000 // 默认每页最大记录数
+	defaultOrder    = "-id" // 默认排序
+
+	defaultIdempotencyTTL = 24 * time.Hour // 幂等键默认有效期
+)
+
+// CreateResponseShape 创建接口的响应结构
+type CreateResponseShape string
+
+const (
+	CreateResponseObject   CreateResponseShape = ""         // 返回最后创建的记录（默认），批量创建时只返回最后一条
+	CreateResponseArray    CreateResponseShape = "array"    // 始终返回创建的记录数组，单条创建时数组只有一个元素
+	CreateResponseEnvelope CreateResponseShape = "envelope" // 始终返回与列表一致的结构 {"data": [...], "total": n}
+)
+
+// ResourceOptions 资源路由配置，零值字段使用默认值
+// 可通过 WithOptions 整体传入，也可通过 WithXxx 选项单独设置
+type ResourceOptions struct {
+	LogLevel        string              // 资源日志级别，为空时使用全局配置
+	IncludeDeleted  bool                // 列表总数是否包含软删除的记录
+	StreamThreshold int                 // 列表结果预估内存超过该字节数时改为流式输出，0 为默认阈值，小于 0 时不启用
+	ErrorMessages   map[int]string      // 按状态码自定义的错误信息
+	BatchChunkSize  int                 // 批量创建和更新每块的记录数，0 表示在同一事务中完成
+	Database        string              // 资源绑定的数据库名称，为空时使用全局事务中间件的数据库
+	DefaultPageSize int                 // 未指定 page_size 时的每页记录数，0 为 10
+	MaxPageSize     int                 // 每页最大记录数，0 为 10000
+	DefaultOrder    string              // 未指定 order 时的排序，如 "-id"，为空时按主键降序
+	IdempotencyTTL  time.Duration       // 创建接口 Idempotency-Key 的有效期，0 为 24 小时
+	AfterList       AfterListHook       // 列表查询后、序列化前处理当前页记录的钩子，注册后列表不再流式输出
+	CreateResponse  CreateResponseShape // 创建接口的响应结构，默认返回最后创建的记录
+
+	relations []relation // 引用当前资源的子表，通过 WithRelation 设置
+}
+
+// pageSizeOrDefault 获取未指定 page_size 时的每页记录数
+func (o *ResourceOptions) pageSizeOrDefault() int {
+	if o.DefaultPageSize > 0 {
+		return o.DefaultPageSize
+	}
+	return defaultPageSize
+}
+
+// maxPageSizeOrDefault 获取每页最大记录数
+func (o *ResourceOptions) maxPageSizeOrDefault() int {
+	if o.MaxPageSize > 0 {
+		return o.MaxPageSize
+	}
+	return maxPageSize
+}
+
+// orderOrDefault 获取未指定 order 时的排序
+func (o *ResourceOptions) orderOrDefault() string {
+	if o.DefaultOrder != "" {
+		return o.DefaultOrder
+	}
+	return defaultOrder
+}
+
+// idempotencyTTLOrDefault 获取幂等键的有效期
+func (o *ResourceOptions) idempotencyTTLOrDefault() time.Duration {
+	if o.IdempotencyTTL > 0 {
+		return o.IdempotencyTTL
+	}
+	return defaultIdempotencyTTL
+}
+
+// WithOptions 整体设置资源配置，覆盖之前的选项，已通过 WithRelation 声明的关联关系保留
+func WithOptions(options ResourceOptions) RouteOption {
+	return func(o *ResourceOptions) {
+		relations := o.relations
+		*o = options
+		o.relations = append(relations, options.relations...)
+	}
+}
+
+// WithLogLevel 设置资源的日志级别，如 "debug" 可单独开启该资源的详细日志
+func WithLogLevel(level string) RouteOption {
+	return func(o *ResourceOptions) {
+		o.LogLevel = level
+	}
+}
+
+// WithDeletedInTotal 列表总数包含软删除的记录，用于展示历史总量，启用后不再使用计数器
+func WithDeletedInTotal() RouteOption {
+	return func(o *ResourceOptions) {
+		o.IncludeDeleted = true
+	}
+}
+
+// WithStreamThreshold 设置列表流式输出的阈值，按 page_size 与估算的单行大小计算结果占用的内存，
+// 超过 bytes 字节时逐行扫描并输出，避免单个请求占用过多内存；bytes 小于 0 时不启用
+func WithStreamThreshold(bytes int) RouteOption {
+	return func(o *ResourceOptions) {
+		o.StreamThreshold = bytes
+	}
+}
+
+// WithErrorMessage 自定义资源指定状态码的错误信息，如 404 返回 "user not found"，错误码和字段错误保持不变
+func WithErrorMessage(status int, message string) RouteOption {
+	return func(o *ResourceOptions) {
+		if o.ErrorMessages == nil {
+			o.ErrorMessages = make(map[int]string)
+		}
+		o.ErrorMessages[status] = message
+	}
+}
+
+// WithBatchChunkSize 批量创建和批量更新每处理 size 条记录提交一次事务，之后的记录在新事务中处理，缩短大批量操作持有锁的时间
+// 启用后批量操作不再是原子的：某块失败时只回滚该块，之前已提交的块保留，已提交的块通过 X-Committed-Chunks 响应头返回
+func WithBatchChunkSize(size int) RouteOption {
+	return func(o *ResourceOptions) {
+		o.BatchChunkSize = size
+	}
+}
+
+// WithDatabase 将资源绑定到以 utils.RegisterDataBase 注册的数据库，资源的请求在该数据库的事务中处理
+func WithDatabase(name string) RouteOption {
+	return func(o *ResourceOptions) {
+		o.Database = name
+	}
+}
+
+// WithAfterList 注册列表结果处理钩子，在查询和解密之后、序列化之前以当前页的记录切片调用
+// 钩子需要完整的结果切片，注册后列表不再按 StreamThreshold 流式输出
+func WithAfterList(hook AfterListHook) RouteOption {
+	return func(o *ResourceOptions) {
+		o.AfterList = hook
+	}
+}
+
+// WithCreateResponse 设置创建接口的响应结构，使单条和批量创建返回一致的结构
+func WithCreateResponse(shape CreateResponseShape) RouteOption {
+	return func(o *ResourceOptions) {
+		o.CreateResponse = shape
+	}
+}
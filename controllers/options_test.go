@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"minigo/utils"
+)
+
+func TestResourceOptions(t *testing.T) {
+	db := openTestDataBase(t, csvItem{})
+	bound := openTestDataBase(t, csvItem{})
+	// 每次运行使用不同的名称，避免重复运行时与已注册的实例冲突
+	name := "resource_options_" + t.TempDir()
+	if err := utils.RegisterDataBase(name, bound); err != nil {
+		t.Fatalf("failed to register database: %v", err)
+	}
+
+	// 记录处理完成后请求上下文中的资源配置
+	var logLevel string
+	var errorMessages interface{}
+	r := newTestRouter(db, 0, func(c *gin.Context) {
+		c.Next()
+		logLevel = c.GetString("log_level")
+		errorMessages, _ = c.Get("error_messages")
+	})
+	options := ResourceOptions{
+		LogLevel:        "error",
+		IncludeDeleted:  true,
+		ErrorMessages:   map[int]string{http.StatusNotFound: "item not found"},
+		BatchChunkSize:  2,
+		Database:        name,
+		DefaultPageSize: 2,
+		MaxPageSize:     3,
+		DefaultOrder:    "id",
+		CreateResponse:  CreateResponseEnvelope,
+	}
+	// WithOptions 覆盖之前的选项
+	path := registerModel(r, db, csvItem{}, WithLogLevel("debug"), WithOptions(options))
+
+	// 批量创建按块提交，返回与列表一致的结构，记录写入绑定的数据库
+	w := request(r, http.MethodPost, path, `[{"name":"a"},{"name":"b"},{"name":"c"},{"name":"d"},{"name":"e"}]`)
+	expectStatus(t, w, http.StatusCreated)
+	if chunks := w.Header().Get("X-Committed-Chunks"); chunks != "0-1,2-3,4-4" {
+		t.Fatalf("committed chunks = %q, want 0-1,2-3,4-4", chunks)
+	}
+	if body := decode(t, w); body["total"] != float64(5) || len(body["data"].([]interface{})) != 5 {
+		t.Fatalf("create response = %v", body)
+	}
+	var count, boundCount int64
+	db.Model(&csvItem{}).Count(&count)
+	bound.Model(&csvItem{}).Count(&boundCount)
+	if count != 0 || boundCount != 5 {
+		t.Fatalf("records in default/bound database = %d/%d, want 0/5", count, boundCount)
+	}
+	if logLevel != "error" || !reflect.DeepEqual(errorMessages, options.ErrorMessages) {
+		t.Fatalf("context log level = %q, error messages = %v", logLevel, errorMessages)
+	}
+	bound.Delete(&csvItem{}, 5)
+
+	tests := []struct {
+		query string
+		ids   []int
+	}{
+		// 默认每页记录数和默认排序
+		{"", []int{1, 2}},
+		// 每页记录数不超过上限
+		{"?page_size=100", []int{1, 2, 3}},
+		// 显式指定的排序优先
+		{"?order=-id", []int{4, 3}},
+	}
+	for _, tt := range tests {
+		w := request(r, http.MethodGet, path+tt.query, "")
+		expectStatus(t, w, http.StatusOK)
+		body := decode(t, w)
+		var ids []int
+		for _, item := range body["data"].([]interface{}) {
+			ids = append(ids, int(item.(map[string]interface{})["id"].(float64)))
+		}
+		// 总数包含软删除的记录
+		if !reflect.DeepEqual(ids, tt.ids) || body["total"] != float64(5) {
+			t.Fatalf("%q: ids = %v, total = %v, want %v and 5", tt.query, ids, body["total"], tt.ids)
+		}
+	}
+
+	w = request(r, http.MethodGet, path+"/99", "")
+	expectStatus(t, w, http.StatusNotFound)
+	if err := decode(t, w)["error"].(map[string]interface{}); err["message"] != "item not found" {
+		t.Fatalf("not found error = %v", err)
+	}
+}
@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+
+	"minigo/utils"
+)
+
+const (
+	cursorNext = "next" // 向后翻页
+	cursorPrev = "prev" // 向前翻页
+)
+
+// pageCursor 游标分页的位置信息
+type pageCursor struct {
+	ID        string `json:"id"`        // 边界记录的主键
+	Direction string `json:"direction"` // 翻页方向
+	Desc      bool   `json:"desc"`      // 生成游标时列表是否按主键降序展示
+}
+
+// encodeCursor 将边界主键、翻页方向和排序方向编码为游标
+func encodeCursor(id interface{}, direction string, desc bool) *string {
+	data, _ := json.Marshal(pageCursor{ID: fmt.Sprint(id), Direction: direction, Desc: desc})
+	token := base64.RawURLEncoding.EncodeToString(data)
+	return &token
+}
+
+// decodeCursor 解析游标
+func decodeCursor(token string) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %v", err)
+	}
+
+	var cursor pageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("invalid cursor: %v", err)
+	}
+	if cursor.ID == "" || (cursor.Direction != cursorNext && cursor.Direction != cursorPrev) {
+		return nil, fmt.Errorf("invalid cursor: %s", token)
+	}
+	return &cursor, nil
+}
+
+// primaryKeyOf 获取查询模型的主键列名和字段名，无法解析时使用 id 列
+func primaryKeyOf(query *gorm.DB) (string, string) {
+	if err := query.Statement.Parse(query.Statement.Model); err == nil {
+		if field := query.Statement.Schema.PrioritizedPrimaryField; field != nil {
+			return field.DBName, field.Name
+		}
+	}
+	return "id", "ID"
+}
+
+// cursorPaginate 基于主键的游标分页，支持向后(next)和向前(prev)翻页
+// token 为空表示第一页；desc 表示列表按主键降序展示，须与生成游标时的排序方向一致
+// 游标只依赖主键的大小顺序，不要求主键连续，自增步长大于 1 或存在空洞时同样适用
+func cursorPaginate(query *gorm.DB, modelType reflect.Type, pageSize int, token string, desc bool) (interface{}, *string, *string, error) {
+	var cursor *pageCursor
+	if token != "" {
+		var err error
+		if cursor, err = decodeCursor(token); err != nil {
+			return nil, nil, nil, err
+		}
+		// 翻页期间改变排序方向时边界条件和结果顺序都会错乱
+		if cursor.Desc != desc {
+			return nil, nil, nil, fmt.Errorf("invalid cursor: order changed between pages")
+		}
+	}
+
+	// 向前翻页时需要反转排序方向，查询后再将结果反转回展示顺序
+	backward := cursor != nil && cursor.Direction == cursorPrev
+	scanDesc := desc != backward
+
+	pkColumn, pkField := primaryKeyOf(query)
+	if cursor != nil {
+		// 按模型主键类型转换边界值，整数主键按数值比较
+		boundary, err := utils.ParsePrimaryKey(modelType, cursor.ID)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid cursor: %v", err)
+		}
+		if scanDesc {
+			query = query.Where(fmt.Sprintf("%s < ?", pkColumn), boundary)
+		} else {
+			query = query.Where(fmt.Sprintf("%s > ?", pkColumn), boundary)
+		}
+	}
+	if scanDesc {
+		query = query.Order(fmt.Sprintf("%s DESC", pkColumn))
+	} else {
+		query = query.Order(fmt.Sprintf("%s ASC", pkColumn))
+	}
+
+	// 多取一条用于判断翻页方向上是否还有数据
+	results := reflect.New(reflect.SliceOf(modelType)).Elem()
+	if err := query.Limit(pageSize + 1).Find(results.Addr().Interface()).Error; err != nil {
+		return nil, nil, nil, err
+	}
+	hasMore := results.Len() > pageSize
+	if hasMore {
+		results = results.Slice(0, pageSize)
+	}
+	if backward {
+		for i, j := 0, results.Len()-1; i < j; i, j = i+1, j-1 {
+			first, last := results.Index(i).Interface(), results.Index(j).Interface()
+			results.Index(i).Set(reflect.ValueOf(last))
+			results.Index(j).Set(reflect.ValueOf(first))
+		}
+	}
+	if results.Len() == 0 {
+		return results.Interface(), nil, nil, nil
+	}
+
+	firstID := results.Index(0).FieldByName(pkField).Interface()
+	lastID := results.Index(results.Len() - 1).FieldByName(pkField).Interface()
+
+	var next, prev *string
+	switch {
+	case cursor == nil:
+		// 第一页没有上一页
+		if hasMore {
+			next = encodeCursor(lastID, cursorNext, desc)
+		}
+	case backward:
+		// 从后一页翻回来，下一页必然存在
+		next = encodeCursor(lastID, cursorNext, desc)
+		if hasMore {
+			prev = encodeCursor(firstID, cursorPrev, desc)
+		}
+	default:
+		// 从前一页翻过来，上一页必然存在
+		prev = encodeCursor(firstID, cursorPrev, desc)
+		if hasMore {
+			next = encodeCursor(lastID, cursorNext, desc)
+		}
+	}
+
+	return results.Interface(), next, prev, nil
+}
+
+// pageSnapshot 列表快照，记录首次查询时的最大主键，后续翻页只返回不超过该主键的记录
+type pageSnapshot struct {
+	MaxID string `json:"max_id"` // 快照时的最大主键，为空表示快照时没有记录
+}
+
+// encodeSnapshot 将快照编码为令牌
+func encodeSnapshot(snapshot pageSnapshot) string {
+	data, _ := json.Marshal(snapshot)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeSnapshot 解析快照令牌
+func decodeSnapshot(token string) (*pageSnapshot, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot: %v", err)
+	}
+
+	var snapshot pageSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("invalid snapshot: %v", err)
+	}
+	return &snapshot, nil
+}
+
+// applySnapshot 为查询添加快照边界，token 为空时以当前最大主键创建新快照，返回添加条件后的查询和快照令牌
+// 快照边界屏蔽翻页期间新增的记录，避免偏移分页出现重复或遗漏；期间删除的记录仍会影响后续页
+func applySnapshot(db *gorm.DB, query *gorm.DB, modelType reflect.Type, token string) (*gorm.DB, string, error) {
+	pkColumn, _ := primaryKeyOf(query)
+
+	var snapshot *pageSnapshot
+	if token == "" {
+		// 包含软删除的记录，保证快照边界不受删除影响
+		var maxID sql.NullString
+		row := db.Model(query.Statement.Model).Unscoped().Select(fmt.Sprintf("MAX(%s)", pkColumn)).Row()
+		if err := row.Scan(&maxID); err != nil {
+			return nil, "", err
+		}
+		snapshot = &pageSnapshot{MaxID: maxID.String}
+		token = encodeSnapshot(*snapshot)
+	} else {
+		var err error
+		if snapshot, err = decodeSnapshot(token); err != nil {
+			return nil, "", err
+		}
+	}
+
+	// 快照时没有记录，后续新增的记录均不可见
+	if snapshot.MaxID == "" {
+		return query.Where("1 = 0"), token, nil
+	}
+
+	boundary, err := utils.ParsePrimaryKey(modelType, snapshot.MaxID)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid snapshot: %v", err)
+	}
+	return query.Where(fmt.Sprintf("%s <= ?", pkColumn), boundary), token, nil
+}
@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"minigo/models"
+)
+
+// cursorPage 请求一页游标分页结果，返回主键列表和下一页、上一页游标
+func cursorPage(t *testing.T, r *gin.Engine, path, query string) ([]int, string, string) {
+	t.Helper()
+	w := request(r, http.MethodGet, path+"?"+query, "")
+	expectStatus(t, w, http.StatusOK)
+	body := decode(t, w)
+
+	var ids []int
+	for _, item := range body["data"].([]interface{}) {
+		ids = append(ids, int(item.(map[string]interface{})["id"].(float64)))
+	}
+	next, _ := body["next_cursor"].(string)
+	prev, _ := body["prev_cursor"].(string)
+	return ids, next, prev
+}
+
+func TestCursorPagination(t *testing.T) {
+	r, db := setupTest(t, csvItem{})
+	path := "/api/csv_items"
+	// 主键不连续（如自增步长为 3），游标只依赖主键的大小顺序
+	for _, id := range []uint{1, 4, 7, 10, 13} {
+		db.Create(&csvItem{BaseModel: models.BaseModel{ID: id}, Name: fmt.Sprintf("item%d", id)})
+	}
+
+	tests := []struct {
+		order string
+		pages [][]int
+	}{
+		{"id", [][]int{{1, 4}, {7, 10}, {13}}},
+		{"-id", [][]int{{13, 10}, {7, 4}, {1}}},
+	}
+	for _, tt := range tests {
+		// 向后翻到最后一页
+		ids, next, _ := cursorPage(t, r, path, "page_size=2&cursor=&order="+tt.order)
+		if !reflect.DeepEqual(ids, tt.pages[0]) {
+			t.Fatalf("order %s first page = %v, want %v", tt.order, ids, tt.pages[0])
+		}
+		ids, next, _ = cursorPage(t, r, path, "page_size=2&order="+tt.order+"&cursor="+url.QueryEscape(next))
+		if !reflect.DeepEqual(ids, tt.pages[1]) {
+			t.Fatalf("order %s second page = %v, want %v", tt.order, ids, tt.pages[1])
+		}
+		ids, next, prev := cursorPage(t, r, path, "page_size=2&order="+tt.order+"&cursor="+url.QueryEscape(next))
+		if !reflect.DeepEqual(ids, tt.pages[2]) || next != "" {
+			t.Fatalf("order %s last page = %v (next %q), want %v", tt.order, ids, next, tt.pages[2])
+		}
+
+		// 向前翻回第一页
+		ids, _, prev = cursorPage(t, r, path, "page_size=2&order="+tt.order+"&cursor="+url.QueryEscape(prev))
+		if !reflect.DeepEqual(ids, tt.pages[1]) {
+			t.Fatalf("order %s previous page = %v, want %v", tt.order, ids, tt.pages[1])
+		}
+		ids, next, prev = cursorPage(t, r, path, "page_size=2&order="+tt.order+"&cursor="+url.QueryEscape(prev))
+		if !reflect.DeepEqual(ids, tt.pages[0]) || prev != "" || next == "" {
+			t.Fatalf("order %s back to first page = %v (prev %q), want %v", tt.order, ids, prev, tt.pages[0])
+		}
+	}
+
+	// 游标分页不支持按其他字段排序
+	w := request(r, http.MethodGet, path+"?cursor=&order=name", "")
+	expectStatus(t, w, http.StatusBadRequest)
+
+	// 翻页期间改变排序方向时拒绝游标
+	_, next, _ := cursorPage(t, r, path, "page_size=2&cursor=&order=id")
+	w = request(r, http.MethodGet, path+"?page_size=2&order=-id&cursor="+url.QueryEscape(next), "")
+	expectStatus(t, w, http.StatusBadRequest)
+}
+
+// offsetPage 请求一页偏移分页结果，返回主键列表和快照令牌
+func offsetPage(t *testing.T, r *gin.Engine, path, query string) ([]int, string) {
+	t.Helper()
+	w := request(r, http.MethodGet, path+"?"+query, "")
+	expectStatus(t, w, http.StatusOK)
+	body := decode(t, w)
+
+	var ids []int
+	for _, item := range body["data"].([]interface{}) {
+		ids = append(ids, int(item.(map[string]interface{})["id"].(float64)))
+	}
+	snapshot, _ := body["snapshot"].(string)
+	return ids, snapshot
+}
+
+func TestSnapshotPagination(t *testing.T) {
+	r, db := setupTest(t, csvItem{})
+	path := "/api/csv_items"
+	for i := 1; i <= 5; i++ {
+		db.Create(&csvItem{Name: fmt.Sprintf("item%d", i)})
+	}
+
+	ids, snapshot := offsetPage(t, r, path, "page_size=2&page=1&snapshot=")
+	if !reflect.DeepEqual(ids, []int{5, 4}) || snapshot == "" {
+		t.Fatalf("first page = %v (snapshot %q)", ids, snapshot)
+	}
+
+	// 翻页期间新增记录，快照翻页既不重复也不遗漏
+	db.Create(&csvItem{Name: "item6"})
+	db.Create(&csvItem{Name: "item7"})
+	seen := ids
+	for page := 2; page <= 4; page++ {
+		ids, token := offsetPage(t, r, path, fmt.Sprintf("page_size=2&page=%d&snapshot=%s", page, url.QueryEscape(snapshot)))
+		if token != snapshot {
+			t.Fatalf("page %d snapshot = %q, want %q", page, token, snapshot)
+		}
+		seen = append(seen, ids...)
+	}
+	if !reflect.DeepEqual(seen, []int{5, 4, 3, 2, 1}) {
+		t.Fatalf("snapshot pages = %v, want [5 4 3 2 1]", seen)
+	}
+
+	// 不使用快照时新增的记录使后续页出现重复
+	if ids, _ := offsetPage(t, r, path, "page_size=2&page=2"); !reflect.DeepEqual(ids, []int{5, 4}) {
+		t.Fatalf("page 2 without snapshot = %v, want [5 4]", ids)
+	}
+
+	w := request(r, http.MethodGet, path+"?snapshot=invalid", "")
+	expectStatus(t, w, http.StatusBadRequest)
+}
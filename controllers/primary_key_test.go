@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/soft_delete"
+
+	"minigo/utils"
+)
+
+// uuidItem 主键列不是 id 的模型
+type uuidItem struct {
+	UUID      string                `json:"uuid" gorm:"primarykey;type:varchar(36)"`
+	DeletedAt soft_delete.DeletedAt `json:"-"`
+	Name      string                `json:"name" ctags:"name,q,u"`
+}
+
+func (i *uuidItem) BeforeCreate(tx *gorm.DB) error {
+	if i.UUID == "" {
+		i.UUID = utils.NewUUID()
+	}
+	return nil
+}
+
+func TestUUIDPrimaryKey(t *testing.T) {
+	r, db := setupTest(t, uuidItem{})
+	items := []uuidItem{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}}
+	if err := db.Create(&items).Error; err != nil {
+		t.Fatalf("failed to create records: %v", err)
+	}
+	path := "/api/uuid_items/"
+
+	w := request(r, http.MethodGet, path+items[0].UUID, "")
+	expectStatus(t, w, http.StatusOK)
+	if body := decode(t, w); body["name"] != "a" {
+		t.Fatalf("retrieved wrong record: %v", body)
+	}
+	expectStatus(t, request(r, http.MethodGet, path+utils.NewUUID(), ""), http.StatusNotFound)
+
+	expectStatus(t, request(r, http.MethodPut, path+items[0].UUID, `{"name":"a2"}`), http.StatusOK)
+	expectStatus(t, request(r, http.MethodPut, path+utils.NewUUID(), `{"name":"x"}`), http.StatusNotFound)
+
+	w = request(r, http.MethodPut, "/api/uuid_items", `{"objs":[{"id":"`+items[1].UUID+`","name":"b2"}]}`)
+	expectStatus(t, w, http.StatusOK)
+
+	expectStatus(t, request(r, http.MethodDelete, path+items[2].UUID, ""), http.StatusOK)
+	w = request(r, http.MethodDelete, "/api/uuid_items", `{"ids":["`+items[3].UUID+`","`+utils.NewUUID()+`"]}`)
+	expectStatus(t, w, http.StatusOK)
+	if notFound, _ := decode(t, w)["not_found"].([]interface{}); len(notFound) != 1 {
+		t.Fatalf("not_found = %v, want 1 id", notFound)
+	}
+
+	var names []string
+	db.Model(&uuidItem{}).Order("name").Pluck("name", &names)
+	if len(names) != 2 || names[0] != "a2" || names[1] != "b2" {
+		t.Fatalf("remaining records = %v, want [a2 b2]", names)
+	}
+}
@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"minigo/utils"
+)
+
+// 删除父记录时关联子记录的处理方式
+const (
+	OnDeleteRestrict = "restrict" // 存在未删除的子记录时禁止删除
+	OnDeleteCascade  = "cascade"  // 同时删除子记录（子表支持软删除时为软删除）
+	OnDeleteSetNull  = "set_null" // 将子记录外键置空
+)
+
+// relation 资源关联关系
+type relation struct {
+	model      interface{} // 子表模型
+	foreignKey string      // 子表中引用父记录主键的列名
+	onDelete   string      // 删除父记录时的处理方式
+}
+
+// WithRelation 声明引用当前资源的子表及删除时的处理方式
+func WithRelation(child interface{}, foreignKey string, onDelete string) RouteOption {
+	return func(o *ResourceOptions) {
+		o.relations = append(o.relations, relation{
+			model:      child,
+			foreignKey: foreignKey,
+			onDelete:   onDelete,
+		})
+	}
+}
+
+// relationConflictError 存在关联子记录时的删除冲突
+type relationConflictError struct {
+	table string // 子表名
+	count int64  // 关联的子记录数
+}
+
+func (e *relationConflictError) Error() string {
+	return fmt.Sprintf("record is referenced by %d %s", e.count, e.table)
+}
+
+// applyDeleteRelations 在删除父记录前按关联关系处理子记录，需在事务中调用
+// 子表声明了软删除时，已软删除的子记录不计入 restrict 检查
+func applyDeleteRelations(db *gorm.DB, relations []relation, ids []interface{}) error {
+	for _, rel := range relations {
+		_, childPtr, _ := utils.GetModelInfo(rel.model)
+		query := db.Model(childPtr).Where(fmt.Sprintf("%s IN ?", rel.foreignKey), ids)
+
+		switch rel.onDelete {
+		case OnDeleteRestrict:
+			var count int64
+			if err := query.Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return &relationConflictError{table: utils.TableNameOf(db, rel.model), count: count}
+			}
+		case OnDeleteCascade:
+			if err := query.Delete(childPtr).Error; err != nil {
+				return err
+			}
+		case OnDeleteSetNull:
+			if err := query.Update(rel.foreignKey, nil).Error; err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported on delete behavior: %s", rel.onDelete)
+		}
+	}
+	return nil
+}
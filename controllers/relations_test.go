@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/plugin/soft_delete"
+
+	"minigo/models"
+	"minigo/utils"
+)
+
+// relParent 关联关系测试使用的父表模型
+type relParent struct {
+	models.BaseModel
+	DeletedAt soft_delete.DeletedAt `json:"-"`
+	Name      string                `json:"name" ctags:"name,q,u"`
+}
+
+// relChild 关联关系测试使用的子表模型，parent_id 引用父记录
+type relChild struct {
+	models.BaseModel
+	DeletedAt soft_delete.DeletedAt `json:"-"`
+	ParentID  *uint                 `json:"parent_id"`
+}
+
+// setupRelation 创建父记录 1、2 及各自的一条子记录，以指定的删除方式注册父表路由
+func setupRelation(t *testing.T, onDelete string) (*gin.Engine, *utils.Database) {
+	t.Helper()
+	db := openTestDataBase(t, relParent{}, relChild{})
+	r := newTestRouter(db, 0)
+	registerModel(r, db, relParent{}, WithRelation(relChild{}, "parent_id", onDelete))
+
+	for _, id := range []uint{1, 2} {
+		parentID := id
+		db.Create(&relParent{Name: "parent"})
+		db.Create(&relChild{ParentID: &parentID})
+	}
+	return r, db
+}
+
+// liveCount 统计模型中满足条件的未删除记录数
+func liveCount(db *utils.Database, model interface{}, where string, args ...interface{}) int64 {
+	var count int64
+	db.Model(model).Where(where, args...).Count(&count)
+	return count
+}
+
+func TestDeleteRelationRestrict(t *testing.T) {
+	r, db := setupRelation(t, OnDeleteRestrict)
+
+	w := request(r, http.MethodDelete, "/api/rel_parents/1", "")
+	expectStatus(t, w, http.StatusConflict)
+	if liveCount(db, &relParent{}, "id = 1") != 1 {
+		t.Fatalf("parent deleted despite live children")
+	}
+	w = request(r, http.MethodDelete, "/api/rel_parents", `{"ids":[1,2]}`)
+	expectStatus(t, w, http.StatusConflict)
+	if liveCount(db, &relParent{}, "1 = 1") != 2 {
+		t.Fatalf("parents deleted despite live children")
+	}
+
+	// 已软删除的子记录不阻止删除
+	db.Delete(&relChild{}, 1)
+	w = request(r, http.MethodDelete, "/api/rel_parents/1", "")
+	expectStatus(t, w, http.StatusOK)
+}
+
+func TestDeleteRelationCascade(t *testing.T) {
+	r, db := setupRelation(t, OnDeleteCascade)
+
+	w := request(r, http.MethodDelete, "/api/rel_parents/1", "")
+	expectStatus(t, w, http.StatusOK)
+	if liveCount(db, &relChild{}, "parent_id = 1") != 0 {
+		t.Fatalf("children of deleted parent not deleted")
+	}
+	if liveCount(db, &relChild{}, "parent_id = 2") != 1 {
+		t.Fatalf("children of other parent deleted")
+	}
+	// 子表支持软删除时子记录为软删除
+	var total int64
+	db.Unscoped().Model(&relChild{}).Where("parent_id = 1").Count(&total)
+	if total != 1 {
+		t.Fatalf("child should be soft deleted, found %d rows", total)
+	}
+}
+
+func TestDeleteRelationSetNull(t *testing.T) {
+	r, db := setupRelation(t, OnDeleteSetNull)
+
+	w := request(r, http.MethodDelete, "/api/rel_parents", `{"ids":[1]}`)
+	expectStatus(t, w, http.StatusOK)
+	if liveCount(db, &relChild{}, "parent_id IS NULL") != 1 || liveCount(db, &relChild{}, "parent_id = 2") != 1 {
+		t.Fatalf("child foreign key not cleared")
+	}
+	if liveCount(db, &relParent{}, "1 = 1") != 1 {
+		t.Fatalf("parent not deleted")
+	}
+}
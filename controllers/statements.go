@@ -0,0 +1,28 @@
+package controllers
+
+import (
+	"strconv"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"minigo/utils"
+)
+
+// countStatements 开启 sql_stats 功能开关时返回统计 SQL 语句数的数据库实例，用于确认批量操作是否合并为单条语句
+func countStatements(c *gin.Context, db *gorm.DB) *gorm.DB {
+	if db == nil || !utils.FeatureEnabled(c, "sql_stats", false) {
+		return db
+	}
+	ctx, counter := utils.WithStatementCounter(db.Statement.Context)
+	c.Set("sql_statements", counter)
+	return db.WithContext(ctx)
+}
+
+// reportStatements 将统计的 SQL 语句数写入 X-SQL-Statements 响应头，需在写入响应前调用
+func reportStatements(c *gin.Context) {
+	if counter, exists := c.Get("sql_statements"); exists {
+		c.Header("X-SQL-Statements", strconv.FormatInt(counter.(*atomic.Int64).Load(), 10))
+	}
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"minigo/utils"
+)
+
+const (
+	defaultStreamThreshold = 32 << 20 // 列表结果预估内存的默认阈值，超过时改为流式输出
+	approxVarFieldSize     = 64       // 估算行大小时字符串、切片等变长字段的平均字节数
+	streamFlushRows        = 100      // 流式输出时每写出多少条记录发送一次数据
+)
+
+// estimateRowSize 估算单行记录在内存中的大小，变长字段按平均字节数计算
+func estimateRowSize(modelType reflect.Type) int {
+	size := int(modelType.Size())
+	for i := 0; i < modelType.NumField(); i++ {
+		field := modelType.Field(i)
+		switch field.Type.Kind() {
+		case reflect.String, reflect.Slice, reflect.Map:
+			size += approxVarFieldSize
+		case reflect.Struct:
+			// 嵌入结构体自身的大小已计入，只需补充其中的变长字段
+			if field.Anonymous {
+				size += estimateRowSize(field.Type) - int(field.Type.Size())
+			}
+		case reflect.Ptr:
+			// 嵌入结构体指针只计入了指针大小，需补充其指向的结构体
+			if field.Anonymous && field.Type.Elem().Kind() == reflect.Struct {
+				size += estimateRowSize(field.Type.Elem())
+			}
+		}
+	}
+	return size
+}
+
+// shouldStream 判断列表查询是否改为流式输出，threshold 为 0 时使用默认阈值，小于 0 时不启用
+func shouldStream(modelType reflect.Type, pageSize int, threshold int) bool {
+	if threshold == 0 {
+		threshold = defaultStreamThreshold
+	}
+	return threshold > 0 && pageSize*estimateRowSize(modelType) > threshold
+}
+
+// streamList 逐行扫描查询结果并写入响应，不在内存中构建完整的结果切片
+// meta 为响应中除 data 外的字段
+func streamList(c *gin.Context, query *gorm.DB, modelType reflect.Type, meta gin.H) {
+	rows, err := query.Rows()
+	if err != nil {
+		logger := utils.GetLoggerByCtx(c)
+		logger.Ctx(c).Error("failed to query records", zap.Error(err))
+		utils.RespondError(c, http.StatusNotFound, utils.ErrCodeNotFound, "failed to query records", nil)
+		return
+	}
+	defer rows.Close()
+
+	// 响应头写出后无法再修改状态码，之后的错误只能记录日志并中断输出
+	// 标记为流式输出，中间件不缓存响应，记录按批发送给客户端
+	utils.SetStreaming(c)
+	head, _ := json.Marshal(meta)
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(http.StatusOK)
+	c.Writer.Write(bytes.TrimSuffix(head, []byte("}")))
+	if len(meta) > 0 {
+		c.Writer.Write([]byte(","))
+	}
+	c.Writer.Write([]byte(`"data":[`))
+
+	count := 0
+	for rows.Next() {
+		row := reflect.New(modelType).Interface()
+		err := query.ScanRows(rows, row)
+		if err == nil {
+			err = utils.DecryptFields(row)
+		}
+		var data []byte
+		if err == nil {
+			data, err = json.Marshal(row)
+		}
+		if err != nil {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Error("failed to stream records", zap.Error(err), zap.Int("written", count))
+			c.Error(errors.New(err.Error()))
+			c.Abort()
+			return
+		}
+
+		if count > 0 {
+			c.Writer.Write([]byte(","))
+		}
+		c.Writer.Write(data)
+		count++
+		if count%streamFlushRows == 0 {
+			c.Writer.Flush()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		logger := utils.GetLoggerByCtx(c)
+		logger.Ctx(c).Error("failed to stream records", zap.Error(err), zap.Int("written", count))
+		c.Error(errors.New(err.Error()))
+		c.Abort()
+		return
+	}
+	c.Writer.Write([]byte("]}"))
+}
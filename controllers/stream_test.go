@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestShouldStreamThreshold(t *testing.T) {
+	modelType := reflect.TypeOf(csvItem{})
+	rowSize := estimateRowSize(modelType)
+
+	cases := []struct {
+		pageSize  int
+		threshold int
+		want      bool
+	}{
+		{pageSize: 10, threshold: 10*rowSize + 1, want: false},
+		{pageSize: 10, threshold: 10 * rowSize, want: false},
+		{pageSize: 10, threshold: 10*rowSize - 1, want: true},
+		{pageSize: 1000, threshold: -1, want: false},
+		{pageSize: 1000, threshold: 0, want: false},
+		{pageSize: defaultStreamThreshold/rowSize + 1, threshold: 0, want: true},
+	}
+	for _, tc := range cases {
+		if got := shouldStream(modelType, tc.pageSize, tc.threshold); got != tc.want {
+			t.Errorf("shouldStream(page_size=%d, threshold=%d) = %v, want %v", tc.pageSize, tc.threshold, got, tc.want)
+		}
+	}
+}
+
+func TestStreamListPassesThroughMiddlewares(t *testing.T) {
+	db := openTestDataBase(t, csvItem{})
+	items := make([]csvItem, 3*streamFlushRows)
+	for i := range items {
+		items[i].Name = fmt.Sprintf("item-%03d", i)
+	}
+	if err := db.Create(&items).Error; err != nil {
+		t.Fatalf("failed to create records: %v", err)
+	}
+
+	// 设置了重试次数时事务中间件缓存读请求的响应，流式输出的响应需直接写出
+	done := false
+	r := newTestRouter(db, 2, func(c *gin.Context) {
+		c.Next()
+		done = true
+	})
+	path := registerModel(r, db, csvItem{}, WithStreamThreshold(estimateRowSize(reflect.TypeOf(csvItem{}))))
+
+	w := &progressRecorder{ResponseRecorder: httptest.NewRecorder(), done: &done}
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path+"?page_size=1000", nil))
+	expectStatus(t, w.ResponseRecorder, http.StatusOK)
+	if w.early == 0 || w.flushes < 3 {
+		t.Fatalf("records not streamed before the handler finished: %d bytes, %d flushes", w.early, w.flushes)
+	}
+
+	body := decode(t, w.ResponseRecorder)
+	if data, _ := body["data"].([]interface{}); len(data) != len(items) {
+		t.Fatalf("got %d records, want %d", len(data), len(items))
+	}
+	if body["page_size"] != float64(1000) {
+		t.Fatalf("unexpected meta: %v", body["page_size"])
+	}
+
+	// 未超过阈值时按普通响应返回
+	w = &progressRecorder{ResponseRecorder: httptest.NewRecorder(), done: &done}
+	done = false
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path+"?page_size=1", nil))
+	expectStatus(t, w.ResponseRecorder, http.StatusOK)
+	if w.flushes != 0 {
+		t.Fatalf("small page should not be streamed: %d flushes", w.flushes)
+	}
+}
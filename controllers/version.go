@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"minigo/utils"
+)
+
+// versionLock 乐观锁版本字段
+type versionLock struct {
+	column  string // 数据库列名
+	name    string // 请求数据中的字段名
+	counter bool   // 计数器版本号，每次更新时自增；否则为 updated_at，由 GORM 在更新时刷新
+}
+
+// versionLockOf 获取模型的乐观锁版本字段，优先使用 utils.VersionField 声明的计数器字段，其次为整数类型的 updated_at
+// 模型没有可用的版本字段时返回 nil
+func versionLockOf(query *gorm.DB, modelType reflect.Type) *versionLock {
+	if err := query.Statement.Parse(query.Statement.Model); err != nil {
+		return nil
+	}
+	sch := query.Statement.Schema
+
+	if structField, ok := utils.VersionField(modelType); ok {
+		if field := sch.LookUpField(structField.Name); field != nil && field.DBName != "" {
+			return &versionLock{column: field.DBName, name: utils.JSONFieldName(structField), counter: true}
+		}
+	}
+
+	// 时间类型的 updated_at 序列化后精度和格式与数据库不一致，无法按值比较
+	for _, field := range sch.Fields {
+		if field.AutoUpdateTime == 0 || field.DBName == "" {
+			continue
+		}
+		switch field.FieldType.Kind() {
+		case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+			return &versionLock{column: field.DBName, name: utils.JSONFieldName(field.StructField)}
+		}
+	}
+	return nil
+}
+
+// expected 获取客户端提交的已知版本号，未提交时不做版本检查
+func (v *versionLock) expected(data map[string]interface{}) (interface{}, bool) {
+	if v == nil {
+		return nil, false
+	}
+	value, exists := data[v.name]
+	return value, exists && value != nil
+}
+
+// apply 提交了版本号时将其加入更新条件，计数器版本号在更新数据中自增
+// 需在校验和加密之后调用，避免自增表达式参与校验
+func (v *versionLock) apply(query *gorm.DB, updates map[string]interface{}, expected interface{}, checked bool) *gorm.DB {
+	if v == nil {
+		return query
+	}
+	if checked {
+		query = query.Where(clause.Eq{Column: clause.Column{Name: v.column}, Value: expected})
+	}
+	if v.counter {
+		updates[v.column] = gorm.Expr("? + 1", clause.Column{Name: v.column})
+	}
+	return query
+}
+
+// recordExists 判断记录是否存在，用于区分版本冲突和记录不存在
+func recordExists(db *gorm.DB, modelPtr interface{}, id interface{}) bool {
+	var count int64
+	pkColumn, _ := primaryKeyOf(db.Model(modelPtr))
+	db.Model(modelPtr).Where(clause.Eq{Column: clause.Column{Name: pkColumn}, Value: id}).Count(&count)
+	return count > 0
+}
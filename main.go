@@ -1,10 +1,19 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
 	"reflect"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
 	"minigo/controllers"
 	"minigo/middlewares"
@@ -14,7 +23,11 @@ import (
 
 func main() {
 	logger := utils.GetLogger()
-	db := utils.GetDataBase("test.db").SetLogger(logger)
+	db, err := utils.OpenDataBase("test.db")
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	db.SetLogger(logger)
 
 	// 测试日志
 	// logger.Info("Info message")
@@ -25,22 +38,95 @@ func main() {
 	// logger.WithTraceID("trace-abc234").Info("创建用户", zap.String("username", "test"))
 	// logger.Fatal("Fatal message")
 
+	// 错误响应格式，启用后以 RFC 7807 application/problem+json 返回
+	utils.SetProblemJSON(false)
+
+	// 注册 GORM 插件（如分片、链路追踪），需在挂载路由前完成
+	for _, plugin := range []gorm.Plugin{} {
+		if err := db.Use(plugin); err != nil {
+			log.Fatalf("failed to register plugin: %v", err)
+		}
+	}
+
 	// 设置路由
 	r := gin.Default()
 
-	// 注册事务中间件
-	r.Use(middlewares.TransactionMiddleware(db.DB))
+	// 设置可信代理，仅信任本机的反向代理转发的客户端 IP
+	if err := utils.SetTrustedProxies(r, []string{"127.0.0.1", "::1"}); err != nil {
+		log.Fatalf("failed to configure server: %v", err)
+	}
 
-	for _, model := range []interface{}{models.User{}} {
-		modelType, modelPtr, tableName := utils.GetModelInfo(model)
-		// 迁移数据库
-		err := db.DB.AutoMigrate(modelPtr)
-		if err != nil {
-			log.Fatalf("failed to migrate database: %v", err)
+	// 注册链路追踪ID中间件
+	r.Use(middlewares.TraceIDMiddleware())
+
+	// 注册访问日志中间件
+	r.Use(middlewares.AccessLogMiddleware())
+
+	// 注册限流中间件，每个客户端 IP 的写请求平均每秒 20 个，允许 50 个突发请求
+	r.Use(middlewares.RateLimitMiddleware(middlewares.RateLimitConfig{
+		Rate:        20,
+		Burst:       50,
+		Methods:     []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+		ExemptPaths: []string{"/swagger"},
+	}))
+
+	// 注册请求体大小限制中间件，请求体不超过 32MB
+	r.Use(middlewares.BodySizeLimitMiddleware(32 << 20))
+
+	// 注册请求体解压中间件，解压后的请求体不超过 32MB
+	r.Use(middlewares.RequestDecompressMiddleware(32 << 20))
+
+	// 注册响应压缩中间件，超过 1KB 的响应在客户端支持时使用 gzip 压缩
+	r.Use(middlewares.GzipMiddleware(1024))
+
+	// 注册响应大小限制中间件
+	r.Use(middlewares.ResponseSizeLimitMiddleware(32 << 20))
+
+	// 注册功能开关中间件
+	r.Use(middlewares.FeatureFlagMiddleware(map[string]bool{
+		"include_total": true,
+		"sql_stats":     false,
+	}))
+
+	// 注册背压中间件，连接池饱和时拒绝写请求
+	r.Use(middlewares.BackpressureMiddleware(db, 1))
+
+	// 注册超时中间件，读请求允许更长的执行时间
+	r.Use(middlewares.TimeoutMiddleware(10*time.Second, map[string]time.Duration{
+		http.MethodGet: 30 * time.Second,
+	}))
+
+	// 注册查询超时中间件，单个请求内的 SQL 执行超过 5 秒时取消
+	r.Use(middlewares.QueryTimeoutMiddleware(5*time.Second, nil))
+
+	// 注册连接获取超时中间件，连接池耗尽时等待超过 2 秒的请求返回 503
+	r.Use(middlewares.AcquireTimeoutMiddleware(2*time.Second, nil))
+
+	// 注册死锁重试中间件，事务因死锁等并发冲突失败时最多重试 2 次
+	r.Use(middlewares.DeadlockRetryMiddleware(2))
+
+	// 注册事务中间件，Swagger 文档不访问数据库，不开启事务
+	r.Use(middlewares.TransactionMiddleware(db.DB, "/swagger"))
+
+	// 迁移数据库并创建计数器
+	if err := db.Migrate(models.User{}); err != nil {
+		log.Fatalf("failed to migrate database: %v", err)
+	}
+
+	// 检查索引，提示迁移后仍缺失的索引
+	indexes, err := utils.IndexReport(db.Primary(), models.User{})
+	if err != nil {
+		log.Printf("failed to generate index report: %v", err)
+	}
+	for _, index := range indexes {
+		if index.Status == utils.IndexMissing {
+			log.Printf("missing index %s on %s(%s)", index.Name, index.Table, strings.Join(index.Columns, ", "))
 		}
+	}
 
-		// 创建计数器
-		utils.CreateCounter4Table(db, tableName)
+	for _, model := range []interface{}{models.User{}} {
+		modelType, _, _ := utils.GetModelInfo(model)
+		tableName := utils.TableNameOf(db.DB, model)
 
 		// 注册路由
 		controllers.RegisterGenericRoutes(r, "/api/"+tableName, reflect.Zero(modelType).Interface())
@@ -54,11 +140,47 @@ func main() {
 		BasePath:    "/api",
 	})
 	for _, model := range []interface{}{models.User{}} {
-		modelType, _, tableName := utils.GetModelInfo(model)
+		modelType, _, _ := utils.GetModelInfo(model)
+		tableName := utils.TableNameOf(db.DB, model)
 		swaggerGen.GenerateSwaggerDocs(tableName, reflect.Zero(modelType).Interface())
 	}
 	swaggerGen.RegisterSwaggerRoute(r)
 
-	log.Println("server starting on :38080")
-	r.Run(":38080")
+	// 创建 TypeScript 类型生成器
+	tsGen := utils.NewTypeScriptGenerator()
+	for _, model := range []interface{}{models.User{}} {
+		tsGen.GenerateTypes(model)
+	}
+	tsGen.RegisterTypeScriptRoute(r)
+
+	srv := &http.Server{
+		Addr:    ":38080",
+		Handler: r,
+	}
+
+	go func() {
+		log.Println("server starting on :38080")
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("failed to start server: %v", err)
+		}
+	}()
+
+	// 等待中断信号，优雅关闭服务
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Println("server shutting down")
+
+	// 停止接收新请求，等待处理中的请求（及其事务）完成
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("failed to shutdown server: %v", err)
+	}
+
+	// 关闭数据库连接
+	if err := db.Close(); err != nil {
+		log.Printf("failed to close database: %v", err)
+	}
+	log.Println("server exited")
 }
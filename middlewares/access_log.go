@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"minigo/utils"
+)
+
+// AccessLogMiddleware 访问日志中间件，资源配置了日志级别时按资源级别输出，debug 级别会附带请求详情
+func AccessLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		// 执行下一个中间件或处理程序
+		c.Next()
+
+		// 资源日志级别在路由组中间件中设置，需在处理完成后获取
+		logger := utils.GetLoggerByCtx(c).Ctx(c)
+		fields := []zap.Field{
+			zap.Int("status", c.Writer.Status()),
+			zap.Duration("latency", time.Since(start)),
+			zap.String("client_ip", utils.ClientIP(c)),
+		}
+
+		// 详细日志
+		if logger.Core().Enabled(zapcore.DebugLevel) {
+			fields = append(fields,
+				zap.String("query", c.Request.URL.RawQuery),
+				zap.String("content_type", c.ContentType()),
+				zap.Int64("request_size", c.Request.ContentLength),
+				zap.Int("response_size", c.Writer.Size()),
+				zap.String("user_agent", c.Request.UserAgent()),
+				zap.Strings("errors", c.Errors.Errors()),
+			)
+			logger.Debug("access", fields...)
+			return
+		}
+		logger.Info("access", fields...)
+	}
+}
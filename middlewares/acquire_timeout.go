@@ -0,0 +1,21 @@
+package middlewares
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AcquireTimeoutMiddleware 连接获取超时中间件，限制事务中间件从连接池获取连接的最长等待时间
+// 连接池耗尽时超时的请求立即返回 503，而不是一直阻塞到请求超时；需注册在事务中间件之前
+// timeouts 的键与 TimeoutMiddleware 相同，可以是请求方法或方法加路由，均未配置时使用 defaultTimeout，超时时间 <= 0 表示不限制
+func AcquireTimeoutMiddleware(defaultTimeout time.Duration, timeouts map[string]time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout := resolveTimeout(c, defaultTimeout, timeouts); timeout > 0 {
+			c.Set("acquire_timeout", timeout)
+		}
+
+		// 执行下一个中间件或处理程序
+		c.Next()
+	}
+}
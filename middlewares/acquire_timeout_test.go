@@ -0,0 +1,51 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"minigo/utils"
+)
+
+func TestAcquireTimeout(t *testing.T) {
+	db := openDeferredDataBase(t)
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to get sql db: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	r := gin.New()
+	r.Use(AcquireTimeoutMiddleware(50*time.Millisecond, nil))
+	r.Use(TransactionMiddleware(db))
+	r.GET("/parents", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"result": "ok"})
+	})
+
+	// 占用连接池中唯一的连接
+	conn, err := sqlDB.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("failed to acquire connection: %v", err)
+	}
+
+	start := time.Now()
+	w := serve(r, http.MethodGet, "/parents")
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("request waited %v for a connection", elapsed)
+	}
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), utils.ErrCodeUnavailable) {
+		t.Fatalf("exhausted pool response: %d %s", w.Code, w.Body.String())
+	}
+
+	// 连接释放后请求正常执行
+	conn.Close()
+	w = serve(r, http.MethodGet, "/parents")
+	if w.Code != http.StatusOK {
+		t.Fatalf("response after release: %d %s", w.Code, w.Body.String())
+	}
+}
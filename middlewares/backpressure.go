@@ -0,0 +1,51 @@
+package middlewares
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"minigo/utils"
+)
+
+// BackpressureMiddleware 自适应背压中间件，连接池饱和时拒绝写请求并返回 429
+// 饱和判定：使用中的连接数达到最大打开连接数，且自上次检查以来出现了新的等待者
+// 需注册在事务中间件之前，避免被拒绝的请求占用连接
+func BackpressureMiddleware(db *utils.Database, retryAfter int) gin.HandlerFunc {
+	var (
+		mu            sync.Mutex
+		lastWaitCount int64
+	)
+
+	saturated := func() bool {
+		stats, ok := db.Stats().(sql.DBStats)
+		if !ok || stats.MaxOpenConnections <= 0 {
+			return false
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		waiting := stats.WaitCount > lastWaitCount
+		lastWaitCount = stats.WaitCount
+		return waiting && stats.InUse >= stats.MaxOpenConnections
+	}
+
+	return func(c *gin.Context) {
+		// 只对写请求降级，读请求不受影响
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			if saturated() {
+				c.Header("Retry-After", strconv.Itoa(retryAfter))
+				utils.RespondError(c, http.StatusTooManyRequests, utils.ErrCodeTooManyRequests, "too many requests", nil)
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,66 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"minigo/utils"
+)
+
+func TestBackpressure(t *testing.T) {
+	db := openDeferredDataBase(t)
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to get sql db: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	r := gin.New()
+	r.Use(BackpressureMiddleware(&utils.Database{DB: db}, 2))
+	r.GET("/parents", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"result": "ok"})
+	})
+	r.POST("/parents", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"result": "ok"})
+	})
+
+	// 占用连接池中唯一的连接，并让另一个请求排队等待连接
+	conn, err := sqlDB.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("failed to acquire connection: %v", err)
+	}
+	waiter := make(chan struct{})
+	go func() {
+		defer close(waiter)
+		if waiting, err := sqlDB.Conn(context.Background()); err == nil {
+			waiting.Close()
+		}
+	}()
+	for deadline := time.Now().Add(time.Second); sqlDB.Stats().WaitCount == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("no waiter on the saturated pool")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// 连接池饱和时拒绝写请求，读请求不受影响
+	w := serve(r, http.MethodPost, "/parents")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "2" || !strings.Contains(w.Body.String(), utils.ErrCodeTooManyRequests) {
+		t.Fatalf("saturated write response: %d %q %s", w.Code, w.Header().Get("Retry-After"), w.Body.String())
+	}
+	if w = serve(r, http.MethodGet, "/parents"); w.Code != http.StatusOK {
+		t.Fatalf("saturated read response: %d %s", w.Code, w.Body.String())
+	}
+
+	// 连接释放、等待者拿到连接后，写请求恢复
+	conn.Close()
+	<-waiter
+	if w = serve(r, http.MethodPost, "/parents"); w.Code != http.StatusOK {
+		t.Fatalf("write response after recovery: %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,56 @@
+package middlewares
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"minigo/utils"
+)
+
+// BodySizeLimitMiddleware 请求体大小限制中间件，请求体超过 maxBytes 时返回 413，maxBytes <= 0 表示不限制
+// Content-Length 超过限制时直接拒绝；未声明长度（如分块传输）时通过 http.MaxBytesReader 读取，超过限制即停止读取，
+// 读取后的请求体交给后续处理程序，处理程序中的 io.ReadAll 不会读取超过限制的数据
+// 需注册在请求体解压中间件之前，限制的是传输的请求体大小，解压后的大小由解压中间件限制
+func BodySizeLimitMiddleware(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maxBytes <= 0 || c.Request.Body == nil || c.Request.Body == http.NoBody || c.Request.ContentLength == 0 {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > maxBytes {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Warn("request body too large", zap.Int64("size", c.Request.ContentLength), zap.Int64("limit", maxBytes))
+			utils.RespondError(c, http.StatusRequestEntityTooLarge, utils.ErrCodeTooLarge, "request body too large", nil)
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				logger := utils.GetLoggerByCtx(c)
+				logger.Ctx(c).Warn("request body too large", zap.Int64("limit", maxBytes))
+				utils.RespondError(c, http.StatusRequestEntityTooLarge, utils.ErrCodeTooLarge, "request body too large", nil)
+				c.Abort()
+				return
+			}
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Warn("failed to read body", zap.Error(err))
+			utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeInvalidBody, "failed to read request body", nil)
+			c.Abort()
+			return
+		}
+
+		// 替换为已读取的请求体
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		c.Next()
+	}
+}
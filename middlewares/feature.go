@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FeatureFlagMiddleware 功能开关中间件，defaults 为允许的开关及其默认值
+// 开关名 include_total 对应请求头 X-Feature-Include-Total，取值为 true/false/1/0
+func FeatureFlagMiddleware(defaults map[string]bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		features := make(map[string]bool, len(defaults))
+		for name, enabled := range defaults {
+			features[name] = enabled
+
+			// 读取网关传入的请求头覆盖默认值，非法取值忽略
+			header := c.GetHeader(featureHeader(name))
+			if header == "" {
+				continue
+			}
+			if value, err := strconv.ParseBool(header); err == nil {
+				features[name] = value
+			}
+		}
+
+		// 将功能开关设置到上下文中
+		c.Set("features", features)
+
+		c.Next()
+	}
+}
+
+// featureHeader 获取功能开关对应的请求头名称
+func featureHeader(name string) string {
+	parts := strings.Split(name, "_")
+	for i, part := range parts {
+		if part != "" {
+			parts[i] = strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	return "X-Feature-" + strings.Join(parts, "-")
+}
@@ -0,0 +1,45 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"minigo/utils"
+)
+
+func TestFeatureFlag(t *testing.T) {
+	r := gin.New()
+	r.Use(FeatureFlagMiddleware(map[string]bool{"include_total": true, "strict_validation": false}))
+	r.GET("/", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"include_total":     utils.FeatureEnabled(c, "include_total", false),
+			"strict_validation": utils.FeatureEnabled(c, "strict_validation", true),
+			"unknown":           utils.FeatureEnabled(c, "unknown", true),
+		})
+	})
+
+	tests := []struct {
+		headers map[string]string
+		want    string
+	}{
+		// 未传请求头时使用默认值，未配置的开关使用调用处的默认值
+		{nil, `{"include_total":true,"strict_validation":false,"unknown":true}`},
+		{map[string]string{"X-Feature-Include-Total": "false", "X-Feature-Strict-Validation": "1"}, `{"include_total":false,"strict_validation":true,"unknown":true}`},
+		// 非法取值和未配置的开关忽略
+		{map[string]string{"X-Feature-Include-Total": "maybe", "X-Feature-Unknown": "false"}, `{"include_total":true,"strict_validation":false,"unknown":true}`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		for key, value := range tt.headers {
+			req.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Body.String() != tt.want {
+			t.Errorf("headers %v: features = %s, want %s", tt.headers, w.Body.String(), tt.want)
+		}
+	}
+}
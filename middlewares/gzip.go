@@ -0,0 +1,172 @@
+package middlewares
+
+import (
+	"bytes"
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"minigo/utils"
+)
+
+// defaultGzipContentTypes 默认压缩的响应类型
+var defaultGzipContentTypes = []string{
+	"application/json",
+	"application/problem+json",
+	"application/yaml",
+	"application/javascript",
+	"text/csv",
+	"text/html",
+	"text/css",
+	"text/plain",
+}
+
+// gzipWriter 缓存响应体直到超过最小压缩大小，超过后改为压缩输出，未超过时原样写出
+type gzipWriter struct {
+	gin.ResponseWriter
+	c            *gin.Context
+	minSize      int
+	contentTypes []string
+	buffer       bytes.Buffer
+	gzip         *gzip.Writer
+	decided      bool
+}
+
+func (w *gzipWriter) Write(data []byte) (int, error) {
+	if w.decided {
+		if w.gzip != nil {
+			return w.gzip.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+
+	// 流式输出（如 CSV 导出）不等待达到最小压缩大小，立即决定是否压缩，之后每次 Flush 都将数据发送给客户端
+	w.buffer.Write(data)
+	if w.buffer.Len() >= w.minSize || utils.IsStreaming(w.c) {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *gzipWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Written 响应体写入缓存后即视为已写出，避免后续中间件重复写入
+func (w *gzipWriter) Written() bool {
+	return w.buffer.Len() > 0 || w.ResponseWriter.Written()
+}
+
+// WriteHeaderNow 决定是否压缩之前不写出响应头，否则无法再设置 Content-Encoding
+func (w *gzipWriter) WriteHeaderNow() {
+	if w.decided {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+// Flush 流式输出时立即决定是否压缩，并将已压缩的数据发送给客户端
+func (w *gzipWriter) Flush() {
+	if !w.decided {
+		w.decide(w.buffer.Len() > 0)
+	}
+	if w.gzip != nil {
+		w.gzip.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide 决定是否压缩并写出缓存的数据，large 表示响应体已达到最小压缩大小
+// 非 2xx 响应、部分内容响应、已设置 Content-Encoding、类型不在允许列表中时不压缩
+func (w *gzipWriter) decide(large bool) error {
+	w.decided = true
+
+	header := w.Header()
+	status := w.Status()
+	compressible := status >= 200 && status < 300 && status != http.StatusNoContent && status != http.StatusPartialContent
+	if large && compressible && header.Get("Content-Encoding") == "" && w.allowed(header.Get("Content-Type")) {
+		header.Set("Content-Encoding", "gzip")
+		header.Add("Vary", "Accept-Encoding")
+		header.Del("Content-Length")
+		w.gzip = gzip.NewWriter(w.ResponseWriter)
+	}
+
+	data := w.buffer.Bytes()
+	w.buffer = bytes.Buffer{}
+	if len(data) == 0 {
+		return nil
+	}
+	if w.gzip != nil {
+		_, err := w.gzip.Write(data)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(data)
+	return err
+}
+
+// allowed 判断响应类型是否在允许压缩的列表中，忽略 charset 等参数
+func (w *gzipWriter) allowed(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return utils.ExistsIn(w.contentTypes, mediaType)
+}
+
+// close 写出未压缩的缓存数据或结束压缩流
+func (w *gzipWriter) close() error {
+	if !w.decided {
+		return w.decide(false)
+	}
+	if w.gzip != nil {
+		return w.gzip.Close()
+	}
+	return nil
+}
+
+// GzipMiddleware 响应压缩中间件，客户端请求头 Accept-Encoding 包含 gzip 时压缩响应
+// 响应体小于 minSize 字节时不压缩；contentTypes 为允许压缩的响应类型（如 "application/json"），为空时使用默认列表
+// 需注册在事务中间件之前，事务提交或回滚后再写出响应；Swagger UI 的静态文件按类型压缩，范围请求的响应不压缩
+// 流式输出的响应（见 utils.SetStreaming）不缓存，首次写入时即决定是否压缩
+func GzipMiddleware(minSize int, contentTypes ...string) gin.HandlerFunc {
+	if len(contentTypes) == 0 {
+		contentTypes = defaultGzipContentTypes
+	}
+
+	return func(c *gin.Context) {
+		if !acceptsGzip(c.GetHeader("Accept-Encoding")) || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		// 替换响应写入器
+		writer := &gzipWriter{ResponseWriter: c.Writer, c: c, minSize: minSize, contentTypes: contentTypes}
+		c.Writer = writer
+
+		// 执行下一个中间件或处理程序
+		c.Next()
+
+		// 恢复原始写入器并写出剩余数据
+		c.Writer = writer.ResponseWriter
+		writer.close()
+	}
+}
+
+// acceptsGzip 判断 Accept-Encoding 是否接受 gzip，q=0 表示不接受
+func acceptsGzip(acceptEncoding string) bool {
+	for _, encoding := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(encoding), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), "gzip") {
+			continue
+		}
+		if q, found := strings.CutPrefix(strings.ReplaceAll(params, " ", ""), "q="); found {
+			return strings.Trim(q, "0.") != ""
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,20 @@
+package middlewares
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QueryTimeoutMiddleware 查询超时中间件，限制处理程序通过 GetDbByCtx 执行的 SQL 的最长时间，避免慢查询长时间占用连接和事务
+// timeouts 的键与 TimeoutMiddleware 相同，可以是请求方法或方法加路由，均未配置时使用 defaultTimeout，超时时间 <= 0 表示不限制
+func QueryTimeoutMiddleware(defaultTimeout time.Duration, timeouts map[string]time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout := resolveTimeout(c, defaultTimeout, timeouts); timeout > 0 {
+			c.Set("query_timeout", timeout)
+		}
+
+		// 执行下一个中间件或处理程序
+		c.Next()
+	}
+}
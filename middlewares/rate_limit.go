@@ -0,0 +1,122 @@
+package middlewares
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"minigo/utils"
+)
+
+// RateLimitConfig 限流配置
+type RateLimitConfig struct {
+	Rate        float64  // 每秒补充的令牌数，即允许的平均请求速率
+	Burst       int      // 令牌桶容量，即允许的突发请求数，<= 0 时为 1
+	PerRoute    bool     // 按客户端 IP 和路由分别限流，否则同一 IP 的所有路由共享令牌桶
+	Methods     []string // 限流的请求方法，为空时限制所有方法
+	ExemptPaths []string // 不限流的路径前缀，如 "/swagger"
+}
+
+// tokenBucket 令牌桶
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// take 按经过的时间补充令牌后取出一个，令牌不足时返回需要等待的时间
+func (b *tokenBucket) take(now time.Time, rate float64, burst int) (bool, time.Duration) {
+	b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+// RateLimitMiddleware 限流中间件，按客户端 IP（可选同时按路由）使用令牌桶限流，超过限制时返回 429 和 Retry-After
+// 客户端 IP 通过 utils.ClientIP 获取，部署在代理之后时需先通过 utils.SetTrustedProxies 配置可信代理
+func RateLimitMiddleware(config RateLimitConfig) gin.HandlerFunc {
+	burst := config.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+
+	var (
+		mu        sync.Mutex
+		buckets   = make(map[string]*tokenBucket)
+		lastSweep = time.Now()
+	)
+
+	// 令牌桶补满所需的时间，超过该时间未访问的令牌桶与新建的等价，可以清理
+	idle := time.Duration(float64(burst) / config.Rate * float64(time.Second))
+
+	allow := func(key string) (bool, time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		now := time.Now()
+		if now.Sub(lastSweep) > time.Minute {
+			for k, b := range buckets {
+				if now.Sub(b.last) > idle {
+					delete(buckets, k)
+				}
+			}
+			lastSweep = now
+		}
+
+		b, exists := buckets[key]
+		if !exists {
+			b = &tokenBucket{tokens: float64(burst), last: now}
+			buckets[key] = b
+		}
+		return b.take(now, config.Rate, burst)
+	}
+
+	return func(c *gin.Context) {
+		if !rateLimited(c, config) {
+			c.Next()
+			return
+		}
+
+		key := utils.ClientIP(c)
+		if config.PerRoute {
+			key += " " + c.Request.Method + " " + c.FullPath()
+		}
+
+		if ok, wait := allow(key); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			utils.RespondError(c, http.StatusTooManyRequests, utils.ErrCodeTooManyRequests, "too many requests", nil)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// rateLimited 判断请求是否需要限流，未配置速率、路径豁免或请求方法不在限流范围内时不限流
+func rateLimited(c *gin.Context, config RateLimitConfig) bool {
+	if config.Rate <= 0 {
+		return false
+	}
+	for _, prefix := range config.ExemptPaths {
+		if strings.HasPrefix(c.Request.URL.Path, prefix) {
+			return false
+		}
+	}
+	if len(config.Methods) == 0 {
+		return true
+	}
+	for _, method := range config.Methods {
+		if strings.EqualFold(method, c.Request.Method) {
+			return true
+		}
+	}
+	return false
+}
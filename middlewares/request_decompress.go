@@ -0,0 +1,65 @@
+package middlewares
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"minigo/utils"
+)
+
+// RequestDecompressMiddleware 请求体解压中间件，Content-Encoding 为 gzip 时解压请求体后交给后续处理程序
+// 解压后超过 maxBytes 时返回 413，防止压缩炸弹，maxBytes <= 0 表示不限制
+func RequestDecompressMiddleware(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.EqualFold(strings.TrimSpace(c.GetHeader("Content-Encoding")), "gzip") {
+			c.Next()
+			return
+		}
+
+		reader, err := gzip.NewReader(c.Request.Body)
+		if err != nil {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Warn("invalid gzip body", zap.Error(err))
+			utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeInvalidBody, "invalid gzip body", nil)
+			c.Abort()
+			return
+		}
+		defer reader.Close()
+
+		// 多读一个字节用于判断是否超过限制
+		var src io.Reader = reader
+		if maxBytes > 0 {
+			src = io.LimitReader(reader, maxBytes+1)
+		}
+		body, err := io.ReadAll(src)
+		if err != nil {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Warn("invalid gzip body", zap.Error(err))
+			utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeInvalidBody, "invalid gzip body", nil)
+			c.Abort()
+			return
+		}
+		if maxBytes > 0 && int64(len(body)) > maxBytes {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Warn("decompressed body too large", zap.Int64("limit", maxBytes))
+			utils.RespondError(c, http.StatusRequestEntityTooLarge, utils.ErrCodeTooLarge, "request body too large", nil)
+			c.Abort()
+			return
+		}
+
+		// 替换为解压后的请求体，后续处理程序按未压缩的请求读取
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Request.ContentLength = int64(len(body))
+		c.Request.Header.Del("Content-Encoding")
+		c.Request.Header.Set("Content-Length", strconv.Itoa(len(body)))
+
+		c.Next()
+	}
+}
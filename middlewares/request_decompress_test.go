@@ -0,0 +1,68 @@
+package middlewares
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"minigo/utils"
+)
+
+// gzipBody 压缩请求体
+func gzipBody(t *testing.T, data string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(data)); err != nil {
+		t.Fatalf("failed to compress body: %v", err)
+	}
+	zw.Close()
+	return &buf
+}
+
+func TestRequestDecompress(t *testing.T) {
+	r := gin.New()
+	r.Use(RequestDecompressMiddleware(64))
+	r.POST("/", func(c *gin.Context) {
+		contexts, err := utils.UnbindContext(c)
+		if err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": contexts, "encoding": c.GetHeader("Content-Encoding"), "length": c.Request.ContentLength})
+	})
+
+	tests := []struct {
+		body     *bytes.Buffer
+		encoding string
+		status   int
+		want     string
+	}{
+		// 解压后按 JSON 解析
+		{gzipBody(t, `{"name":"item"}`), "gzip", http.StatusOK, `{"data":[{"name":"item"}],"encoding":"","length":15}`},
+		{gzipBody(t, `[{"name":"a"},{"name":"b"}]`), " GZIP ", http.StatusOK, `{"data":[{"name":"a"},{"name":"b"}],"encoding":"","length":27}`},
+		// 未压缩的请求不受影响
+		{bytes.NewBufferString(`{"name":"plain"}`), "", http.StatusOK, `{"data":[{"name":"plain"}],"encoding":"","length":16}`},
+		// 解压后超过限制返回 413，压缩后的大小不影响判断
+		{gzipBody(t, `{"name":"`+strings.Repeat("a", 100)+`"}`), "gzip", http.StatusRequestEntityTooLarge, utils.ErrCodeTooLarge},
+		// 不是合法的 gzip 数据
+		{bytes.NewBufferString(`{"name":"item"}`), "gzip", http.StatusBadRequest, utils.ErrCodeInvalidBody},
+	}
+	for i, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/", tt.body)
+		req.Header.Set("Content-Type", "application/json")
+		if tt.encoding != "" {
+			req.Header.Set("Content-Encoding", tt.encoding)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("case %d: %d %s, want %d %s", i, w.Code, w.Body.String(), tt.status, tt.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"minigo/utils"
+)
+
+// limitWriter 检查响应大小的写入器，不缓存响应体
+// 响应头写出前的首次写入（如 c.JSON 一次写出的完整响应）或声明的 Content-Length 超过限制时丢弃响应，由中间件返回 413；
+// 响应头写出后（流式输出、Flush 之后）的写入直接透传
+type limitWriter struct {
+	gin.ResponseWriter
+	c        *gin.Context
+	maxBytes int
+	rejected bool
+	size     int // 被丢弃的写入大小
+}
+
+func (w *limitWriter) Write(data []byte) (int, error) {
+	if w.rejected {
+		return len(data), nil
+	}
+	if !w.ResponseWriter.Written() && !utils.IsStreaming(w.c) && w.tooLarge(len(data)) {
+		w.rejected = true
+		w.size = len(data)
+		return len(data), nil
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *limitWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Written 响应被丢弃后视为已写出，避免后续中间件重复写入
+func (w *limitWriter) Written() bool {
+	return w.rejected || w.ResponseWriter.Written()
+}
+
+// WriteHeaderNow 首次写入前不写出响应头，否则超过限制时无法再返回 413
+func (w *limitWriter) WriteHeaderNow() {}
+
+// tooLarge 判断本次写入的大小或声明的 Content-Length 是否超过限制
+func (w *limitWriter) tooLarge(size int) bool {
+	if size > w.maxBytes {
+		return true
+	}
+	declared, err := strconv.Atoi(w.Header().Get("Content-Length"))
+	return err == nil && declared > w.maxBytes
+}
+
+// ResponseSizeLimitMiddleware 响应大小限制中间件，序列化后的响应超过 maxBytes 时返回 413，maxBytes <= 0 表示不限制
+// 不缓存响应体，只检查响应头写出前的写入和声明的 Content-Length；流式输出（见 utils.SetStreaming）的响应不受限制
+func ResponseSizeLimitMiddleware(maxBytes int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maxBytes <= 0 {
+			c.Next()
+			return
+		}
+
+		// 替换响应写入器
+		writer := &limitWriter{ResponseWriter: c.Writer, c: c, maxBytes: maxBytes}
+		c.Writer = writer
+
+		// 执行下一个中间件或处理程序
+		c.Next()
+
+		// 恢复原始写入器
+		c.Writer = writer.ResponseWriter
+
+		// 超过限制时返回 413，丢弃的响应声明的长度不再有效
+		if writer.rejected {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Warn("response too large",
+				zap.Int("size", writer.size),
+				zap.Int("limit", maxBytes),
+			)
+			c.Writer.Header().Del("Content-Length")
+			utils.RespondError(c, http.StatusRequestEntityTooLarge, utils.ErrCodeTooLarge, "response too large", nil)
+			return
+		}
+
+		// 写出未写出的响应头（如无响应体的响应）
+		c.Writer.WriteHeaderNow()
+	}
+}
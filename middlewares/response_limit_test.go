@@ -0,0 +1,109 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"minigo/utils"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// serve 执行请求并返回响应
+func serve(r *gin.Engine, method, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestResponseSizeLimit(t *testing.T) {
+	r := gin.New()
+	r.Use(ResponseSizeLimitMiddleware(64))
+	r.GET("/small", func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"name": "ok"})
+	})
+	r.GET("/large", func(c *gin.Context) {
+		c.Header("ETag", `"v1"`)
+		c.JSON(http.StatusOK, gin.H{"name": strings.Repeat("x", 100)})
+	})
+	r.GET("/declared", func(c *gin.Context) {
+		c.Header("Content-Length", "1000")
+		c.Writer.Write([]byte("x"))
+	})
+	r.GET("/empty", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	w := serve(r, http.MethodGet, "/small")
+	if w.Code != http.StatusCreated || w.Body.String() != `{"name":"ok"}` {
+		t.Fatalf("small response: %d %s", w.Code, w.Body.String())
+	}
+
+	w = serve(r, http.MethodGet, "/large")
+	if w.Code != http.StatusRequestEntityTooLarge || !strings.Contains(w.Body.String(), utils.ErrCodeTooLarge) {
+		t.Fatalf("large response: %d %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "xxx") {
+		t.Fatalf("handler body should be discarded: %s", w.Body.String())
+	}
+
+	w = serve(r, http.MethodGet, "/declared")
+	if w.Code != http.StatusRequestEntityTooLarge || w.Header().Get("Content-Length") != "" {
+		t.Fatalf("declared length: %d %v", w.Code, w.Header())
+	}
+
+	w = serve(r, http.MethodGet, "/empty")
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Fatalf("empty response: %d %s", w.Code, w.Body.String())
+	}
+}
+
+// flushRecorder 记录每次 Flush 时已写出的响应体长度
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushed []int
+}
+
+func (w *flushRecorder) Flush() {
+	w.flushed = append(w.flushed, w.Body.Len())
+	w.ResponseRecorder.Flush()
+}
+
+func TestResponseSizeLimitPassesStreamsThrough(t *testing.T) {
+	r := gin.New()
+	r.Use(ResponseSizeLimitMiddleware(64))
+	r.GET("/stream", func(c *gin.Context) {
+		utils.SetStreaming(c)
+		c.Status(http.StatusOK)
+		for i := 0; i < 3; i++ {
+			c.Writer.Write([]byte(strings.Repeat("x", 100)))
+			c.Writer.Flush()
+		}
+	})
+	r.GET("/flushed", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+		c.Writer.Flush()
+		c.Writer.Write([]byte(strings.Repeat("x", 100)))
+	})
+
+	w := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stream", nil))
+	if w.Code != http.StatusOK || w.Body.Len() != 300 {
+		t.Fatalf("stream response: %d %d bytes", w.Code, w.Body.Len())
+	}
+	// 每次 Flush 时之前写入的数据已发送给客户端
+	if len(w.flushed) != 3 || w.flushed[0] != 100 || w.flushed[1] != 200 {
+		t.Fatalf("stream not flushed incrementally: %v", w.flushed)
+	}
+
+	resp := serve(r, http.MethodGet, "/flushed")
+	if resp.Code != http.StatusOK || resp.Body.Len() != 100 {
+		t.Fatalf("flushed response: %d %d bytes", resp.Code, resp.Body.Len())
+	}
+}
@@ -0,0 +1,216 @@
+package middlewares
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mattn/go-sqlite3"
+
+	"minigo/utils"
+)
+
+// retryBackoff 首次重试前的等待时间，之后每次翻倍并加入随机抖动，错开冲突的事务
+const retryBackoff = 10 * time.Millisecond
+
+// DeadlockRetryMiddleware 死锁重试中间件，事务因死锁、序列化冲突或锁等待失败时，由事务中间件回滚后以新事务重新执行处理程序，最多重试 maxRetries 次
+// 需注册在事务中间件之前；重试期间缓存请求体和响应，只写出最后一次尝试的响应
+// 幂等性：重试前事务已回滚，GET/PUT/DELETE 重新执行是安全的；POST 重新执行会再次创建记录，仅在首次尝试已回滚时重试，
+// 客户端重发请求时应携带 Idempotency-Key；分块提交的批量操作已提交部分数据，不会重试；处理程序中调用外部服务等非事务操作会重复执行
+func DeadlockRetryMiddleware(maxRetries int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maxRetries > 0 {
+			c.Set("tx_retries", maxRetries)
+		}
+
+		// 执行下一个中间件或处理程序
+		c.Next()
+	}
+}
+
+// bufferedWriter 缓存响应体，在处理程序结束后再决定如何写出
+// 流式输出（见 utils.SetStreaming）或处理程序调用 Flush 后改为直接写出，之前缓存的数据一并写出
+type bufferedWriter struct {
+	gin.ResponseWriter
+	c        *gin.Context
+	body     *bytes.Buffer
+	streamed bool
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	if w.streamed || utils.IsStreaming(w.c) {
+		w.stream()
+		return w.ResponseWriter.Write(data)
+	}
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush 改为直接写出，并将已写出的数据发送给客户端
+func (w *bufferedWriter) Flush() {
+	w.stream()
+	w.ResponseWriter.Flush()
+}
+
+// stream 写出缓存的数据，之后的写入不再缓存
+func (w *bufferedWriter) stream() {
+	if w.streamed {
+		return
+	}
+	w.streamed = true
+	if w.body.Len() > 0 {
+		w.ResponseWriter.Write(w.body.Bytes())
+		w.body.Reset()
+	}
+}
+
+// Written 响应体写入缓存后即视为已写出，避免后续中间件重复写入
+func (w *bufferedWriter) Written() bool {
+	return w.body.Len() > 0 || w.ResponseWriter.Written()
+}
+
+func (w *bufferedWriter) Size() int {
+	if w.body.Len() == 0 {
+		return w.ResponseWriter.Size()
+	}
+	return w.body.Len()
+}
+
+// txRetry 事务重试状态，缓存请求体和响应，重试前恢复请求和响应的初始状态
+type txRetry struct {
+	maxRetries int
+	body       []byte
+	header     http.Header
+	writer     *bufferedWriter
+}
+
+// newTxRetry 上下文设置了重试次数时开始缓存请求体和响应；未设置时 bufferResponse 为 true 则只缓存响应，否则返回 nil
+func newTxRetry(c *gin.Context, bufferResponse bool) (*txRetry, error) {
+	maxRetries := c.GetInt("tx_retries")
+	if maxRetries <= 0 && !bufferResponse {
+		return nil, nil
+	}
+
+	retry := &txRetry{maxRetries: max(maxRetries, 0), header: c.Writer.Header().Clone()}
+	if maxRetries > 0 && c.Request.Body != nil {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return nil, err
+		}
+		retry.body = body
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	// 替换响应写入器，缓存响应体
+	retry.writer = &bufferedWriter{ResponseWriter: c.Writer, c: c, body: &bytes.Buffer{}}
+	c.Writer = retry.writer
+	return retry, nil
+}
+
+// reset 丢弃上次尝试的响应和错误，恢复请求体，并按重试次数等待
+func (r *txRetry) reset(c *gin.Context, attempt int) {
+	r.restoreResponse(c)
+	c.Errors = c.Errors[:0]
+	if r.body != nil {
+		c.Request.Body = io.NopCloser(bytes.NewReader(r.body))
+	}
+
+	backoff := retryBackoff << attempt
+	backoff += time.Duration(rand.Int63n(int64(backoff)))
+	select {
+	case <-time.After(backoff):
+	case <-c.Request.Context().Done():
+	}
+}
+
+// restoreResponse 丢弃缓存的响应体，恢复状态码和响应头的初始状态
+func (r *txRetry) restoreResponse(c *gin.Context) {
+	r.writer.body.Reset()
+	r.writer.WriteHeader(http.StatusOK)
+	header := c.Writer.Header()
+	for key := range header {
+		delete(header, key)
+	}
+	for key, values := range r.header {
+		header[key] = values
+	}
+}
+
+// discard 丢弃最后一次尝试的响应并恢复原始写入器，由调用方写出错误响应
+func (r *txRetry) discard(c *gin.Context) {
+	r.restoreResponse(c)
+	c.Writer = r.writer.ResponseWriter
+}
+
+// flush 恢复原始写入器并写出最后一次尝试的响应
+func (r *txRetry) flush(c *gin.Context) {
+	c.Writer = r.writer.ResponseWriter
+	if r.writer.body.Len() > 0 {
+		c.Writer.WriteHeaderNow()
+		c.Writer.Write(r.writer.body.Bytes())
+	}
+}
+
+// 可重试的并发冲突错误码
+var (
+	retryableMySQLErrors    = []uint16{1213, 1205}                                // 死锁、锁等待超时
+	retryablePostgresErrors = []string{"40001", "40P01"}                          // 序列化失败、死锁
+	retryableSQLiteErrors   = []sqlite3.ErrNo{sqlite3.ErrBusy, sqlite3.ErrLocked} // 数据库忙、表被锁定
+)
+
+// retryableErrors 判断处理程序记录的错误中是否有可重试的并发冲突
+func retryableErrors(errs []*gin.Error) bool {
+	for _, err := range errs {
+		if retryableError(err.Err) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryableError 按驱动的错误码判断错误是否为可重试的并发冲突：MySQL 1213、1205，PostgreSQL 40001、40P01，SQLite BUSY、LOCKED
+// 处理程序记录的错误通常只保留了错误信息（errors.New(err.Error())），此时按驱动输出错误码的格式匹配
+func retryableError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return utils.ExistsIn(retryableMySQLErrors, mysqlErr.Number)
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return utils.ExistsIn(retryablePostgresErrors, pgErr.Code)
+	}
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return utils.ExistsIn(retryableSQLiteErrors, sqliteErr.Code)
+	}
+
+	// MySQL 格式为 "Error 1213 (40001): ..."，PostgreSQL 格式为 "...(SQLSTATE 40001)"，SQLite 为错误码对应的信息
+	message := err.Error()
+	for _, number := range retryableMySQLErrors {
+		if strings.Contains(message, fmt.Sprintf("Error %d (", number)) || strings.Contains(message, fmt.Sprintf("Error %d:", number)) {
+			return true
+		}
+	}
+	for _, code := range retryablePostgresErrors {
+		if strings.Contains(message, fmt.Sprintf("(SQLSTATE %s)", code)) {
+			return true
+		}
+	}
+	for _, code := range retryableSQLiteErrors {
+		if strings.HasPrefix(message, code.Error()) || strings.Contains(message, ": "+code.Error()) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package middlewares
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/gorm"
+)
+
+func TestRetryableError(t *testing.T) {
+	deadlock := &mysql.MySQLError{Number: 1213, SQLState: [5]byte{'4', '0', '0', '0', '1'}, Message: "Deadlock found when trying to get lock"}
+	serialization := &pgconn.PgError{Severity: "ERROR", Code: "40001", Message: "could not serialize access due to concurrent update"}
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{deadlock, true},
+		{&mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"}, true},
+		{&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}, false},
+		{serialization, true},
+		{&pgconn.PgError{Severity: "ERROR", Code: "40P01", Message: "deadlock detected"}, true},
+		{&pgconn.PgError{Severity: "ERROR", Code: "23505", Message: "duplicate key value"}, false},
+		{sqlite3.Error{Code: sqlite3.ErrBusy}, true},
+		{sqlite3.Error{Code: sqlite3.ErrConstraint}, false},
+		{fmt.Errorf("failed to update: %w", deadlock), true},
+		// 处理程序只记录了错误信息
+		{errors.New(deadlock.Error()), true},
+		{errors.New(serialization.Error()), true},
+		{errors.New(sqlite3.ErrBusy.Error()), true},
+		// 错误信息中出现关键词但不是驱动的错误码
+		{errors.New("invalid value: deadlock"), false},
+		{errors.New("Error 12130: unknown"), false},
+	}
+	for _, tt := range tests {
+		if got := retryableError(tt.err); got != tt.want {
+			t.Errorf("retryableError(%q) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestDeadlockRetry(t *testing.T) {
+	db := openDeferredDataBase(t)
+	r := gin.New()
+	r.Use(DeadlockRetryMiddleware(2))
+	r.Use(TransactionMiddleware(db))
+
+	attempts := 0
+	r.POST("/parents", func(c *gin.Context) {
+		attempts++
+		tx := c.MustGet("tx").(*gorm.DB)
+		if err := tx.Exec("INSERT INTO parents DEFAULT VALUES").Error; err != nil {
+			t.Errorf("failed to insert: %v", err)
+		}
+		// 首次尝试模拟死锁，按处理程序的方式只记录错误信息
+		if attempts == 1 {
+			err := &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+			c.Error(errors.New(err.Error()))
+			c.JSON(http.StatusInternalServerError, gin.H{"result": "deadlock"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"result": "created"})
+	})
+
+	w := serve(r, http.MethodPost, "/parents")
+	if w.Code != http.StatusCreated || w.Body.String() != `{"result":"created"}` {
+		t.Fatalf("retried response: %d %s", w.Code, w.Body.String())
+	}
+	if attempts != 2 {
+		t.Fatalf("attempts = %d, want 2", attempts)
+	}
+
+	// 首次尝试的写入已回滚，只保留重试时的写入
+	var count int64
+	db.Table("parents").Count(&count)
+	if count != 1 {
+		t.Fatalf("parents = %d, want 1", count)
+	}
+}
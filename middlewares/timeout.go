@@ -0,0 +1,54 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"minigo/utils"
+)
+
+// TimeoutMiddleware 请求超时中间件，为请求上下文设置截止时间，数据库操作随上下文取消
+// timeouts 的键可以是请求方法（如 "GET"）或方法加路由（如 "GET /api/users"），路由优先于方法，均未配置时使用 defaultTimeout，超时时间 <= 0 表示不限制
+func TimeoutMiddleware(defaultTimeout time.Duration, timeouts map[string]time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := resolveTimeout(c, defaultTimeout, timeouts)
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		// 替换请求上下文，后续的事务和查询都会继承截止时间
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		// 执行下一个中间件或处理程序
+		c.Next()
+
+		// 超时且处理程序未写出响应时返回 504，处理程序写出的错误响应已由 RespondError 改为 504
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Warn("request timeout", zap.Duration("timeout", timeout))
+			if !c.Writer.Written() {
+				utils.RespondError(c, http.StatusGatewayTimeout, utils.ErrCodeTimeout, "request timeout", nil)
+				c.Abort()
+			}
+		}
+	}
+}
+
+// resolveTimeout 按路由、请求方法、默认值的顺序获取当前请求的超时时间
+func resolveTimeout(c *gin.Context, defaultTimeout time.Duration, timeouts map[string]time.Duration) time.Duration {
+	if timeout, exists := timeouts[c.Request.Method+" "+c.FullPath()]; exists {
+		return timeout
+	}
+	if timeout, exists := timeouts[c.Request.Method]; exists {
+		return timeout
+	}
+	return defaultTimeout
+}
@@ -0,0 +1,70 @@
+package middlewares
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"minigo/utils"
+)
+
+// slowHandler 模拟耗时 delay 的处理程序，请求上下文先取消时按 fail 返回错误或不写出响应
+func slowHandler(delay time.Duration, fail bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		select {
+		case <-time.After(delay):
+			c.JSON(http.StatusOK, gin.H{"result": "ok"})
+		case <-c.Request.Context().Done():
+			if fail {
+				utils.RespondError(c, http.StatusInternalServerError, utils.ErrCodeDatabase, c.Request.Context().Err().Error(), nil)
+			}
+		}
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	r := gin.New()
+	r.Use(TimeoutMiddleware(20*time.Millisecond, map[string]time.Duration{
+		"POST":             time.Second,
+		"GET /unlimited":   0,
+		"POST /restricted": 20 * time.Millisecond,
+	}))
+	r.GET("/silent", slowHandler(200*time.Millisecond, false))
+	r.GET("/failing", slowHandler(200*time.Millisecond, true))
+	r.POST("/failing", slowHandler(50*time.Millisecond, true))
+	r.GET("/unlimited", slowHandler(50*time.Millisecond, true))
+	r.POST("/restricted", slowHandler(200*time.Millisecond, true))
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+	}{
+		// 处理程序未写出响应时由中间件返回 504
+		{http.MethodGet, "/silent", http.StatusGatewayTimeout},
+		// 处理程序因上下文取消写出的错误同样返回 504
+		{http.MethodGet, "/failing", http.StatusGatewayTimeout},
+		// 请求方法和路由使用各自的超时时间
+		{http.MethodPost, "/failing", http.StatusOK},
+		{http.MethodGet, "/unlimited", http.StatusOK},
+		{http.MethodPost, "/restricted", http.StatusGatewayTimeout},
+	}
+	for _, tt := range tests {
+		start := time.Now()
+		w := serve(r, tt.method, tt.path)
+		if w.Code != tt.status {
+			t.Fatalf("%s %s: status = %d, want %d: %s", tt.method, tt.path, w.Code, tt.status, w.Body.String())
+		}
+		if tt.status == http.StatusGatewayTimeout {
+			if !strings.Contains(w.Body.String(), utils.ErrCodeTimeout) || strings.Count(w.Body.String(), `"error"`) != 1 {
+				t.Fatalf("%s %s: unexpected timeout body %s", tt.method, tt.path, w.Body.String())
+			}
+			if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+				t.Fatalf("%s %s: timed out after %v", tt.method, tt.path, elapsed)
+			}
+		}
+	}
+}
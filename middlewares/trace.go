@@ -0,0 +1,51 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"minigo/utils"
+)
+
+// 链路追踪ID请求头，按顺序读取
+var traceIDHeaders = []string{"X-Request-ID", "X-Trace-ID"}
+
+// maxTraceIDLength 客户端传入的链路追踪ID最大长度
+const maxTraceIDLength = 128
+
+// TraceIDMiddleware 链路追踪ID中间件，优先使用请求头中的 ID，没有则生成 UUID
+// ID 保存在上下文中日志配置的 traceID 字段名下，并通过 X-Request-ID 响应头返回
+func TraceIDMiddleware() gin.HandlerFunc {
+	key := utils.GetLogger().TraceIDKey()
+
+	return func(c *gin.Context) {
+		var traceID string
+		for _, header := range traceIDHeaders {
+			if value := c.GetHeader(header); isValidTraceID(value) {
+				traceID = value
+				break
+			}
+		}
+		if traceID == "" {
+			traceID = utils.NewUUID()
+		}
+
+		c.Set(key, traceID)
+		c.Header("X-Request-ID", traceID)
+
+		// 执行下一个中间件或处理程序
+		c.Next()
+	}
+}
+
+// isValidTraceID 校验客户端传入的链路追踪ID，仅允许长度有限的可见 ASCII 字符，避免日志注入
+func isValidTraceID(traceID string) bool {
+	if traceID == "" || len(traceID) > maxTraceIDLength {
+		return false
+	}
+	for i := 0; i < len(traceID); i++ {
+		if traceID[i] < 0x21 || traceID[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -1,37 +1,179 @@
 package middlewares
 
 import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+
+	"minigo/utils"
 )
 
 // TransactionMiddleware 自动事务中间件
-func TransactionMiddleware(db *gorm.DB) gin.HandlerFunc {
+// OPTIONS 请求、未匹配路由的请求以及 skipPaths 前缀下的请求（如 "/swagger"）不访问数据库，不开启事务
+// 通过 DeadlockRetryMiddleware 设置了重试次数时，事务因死锁等并发冲突失败后以新事务重新执行处理程序
+// 写请求的响应在事务提交后才写出，提交失败时丢弃处理程序的响应并返回 500；读请求不缓存响应（设置了重试次数时除外）
+// 流式输出的响应（见 utils.SetStreaming）不缓存，直接写出，不再重试
+func TransactionMiddleware(db *gorm.DB, skipPaths ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 开启事务
-		tx := db.Begin()
+		if skipTransaction(c, skipPaths) {
+			c.Next()
+			return
+		}
+
+		// 配置了只读副本时，读请求的事务在副本上开启，写请求的事务固定在主库上开启
+		operation := dbresolver.Write
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			operation = dbresolver.Read
+		}
 
-		// 将事务设置到上下文中
-		c.Set("tx", tx)
+		// 嵌套使用时（如资源绑定了其他数据库）保存外层事务，结束后恢复，外层事务由外层中间件提交或回滚
+		// 内层事务自行重试，结束时设置 tx_nested，外层不再重试
+		if outerTx, exists := c.Get("tx"); exists {
+			outerDB, _ := c.Get("tx_db")
+			defer func() {
+				c.Set("tx", outerTx)
+				c.Set("tx_db", outerDB)
+				c.Set("tx_nested", true)
+			}()
+		}
+
+		// 写请求缓存响应，提交成功后再写出；设置了重试次数时同时缓存请求体，只写出最后一次尝试的响应
+		retry, err := newTxRetry(c, operation == dbresolver.Write)
+		if err != nil {
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Warn("failed to read body", zap.Error(err))
+			utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeInvalidBody, "invalid request body", nil)
+			c.Abort()
+			return
+		}
+		if retry != nil {
+			// 处理程序 panic 时同样恢复原始写入器，由外层的 Recovery 写出响应
+			defer func() { c.Writer = retry.writer.ResponseWriter }()
+		}
 
-		// 捕获 panic，回滚事务
-		defer func() {
-			if r := recover(); r != nil {
-				tx.Rollback()
-				panic(r) // 继续抛出 panic
+		// 首次执行后续的中间件和处理程序，重试时只重新执行处理程序，路由组中间件设置的上下文首次执行时已设置
+		next := c.Next
+		for attempt := 0; ; attempt++ {
+			retryable, commitErr := runTransaction(c, db, operation, next)
+			if retry == nil {
+				return
 			}
-		}()
+			// 流式输出的响应已发送给客户端，不能重试，提交失败时也无法再替换响应，只记录错误
+			if retry.writer.streamed {
+				if commitErr != nil {
+					c.Error(commitErr)
+				}
+				return
+			}
+			if !retryable || attempt >= retry.maxRetries || c.GetBool("tx_nested") || c.Request.Context().Err() != nil {
+				if commitErr != nil {
+					retry.discard(c)
+					c.Error(commitErr)
+					utils.RespondError(c, http.StatusInternalServerError, utils.ErrCodeDatabase, "failed to commit transaction", nil)
+					return
+				}
+				retry.flush(c)
+				return
+			}
+
+			logger := utils.GetLoggerByCtx(c)
+			logger.Ctx(c).Warn("retry transaction", zap.Int("attempt", attempt+1), zap.Strings("errors", c.Errors.Errors()))
+			retry.reset(c, attempt)
+			next = func() { c.Handler()(c) }
+		}
+	}
+}
+
+// runTransaction 开启事务并执行处理程序，根据结果提交或回滚，返回事务是否因可重试的并发冲突失败以及提交失败的错误
+// 开启事务失败或已分块提交了部分数据时不可重试
+func runTransaction(c *gin.Context, db *gorm.DB, operation dbresolver.Operation, next func()) (bool, error) {
+	// 设置了连接获取超时（acquire_timeout）时，超时未开启事务则取消上下文，开启后停止计时，事务不受影响
+	ctx := c.Request.Context()
+	var acquireTimer *time.Timer
+	if timeout := c.GetDuration("acquire_timeout"); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		acquireTimer = time.AfterFunc(timeout, cancel)
+	}
 
-		// 执行下一个中间件或处理程序
-		c.Next()
+	// 开启事务，事务绑定请求上下文，请求超时或取消时数据库操作随之中止
+	base := db.WithContext(ctx).Clauses(operation)
+	tx := base.Begin()
 
-		// 根据响应状态提交或回滚事务
-		if len(c.Errors) > 0 {
+	// 计时已触发说明获取连接超时，即使事务恰好开启也已随上下文取消，返回 503
+	if acquireTimer != nil && !acquireTimer.Stop() {
+		if tx.Error == nil {
 			tx.Rollback()
-		} else {
-			if err := tx.Commit().Error; err != nil {
-				tx.Rollback()
-			}
+		}
+		logger := utils.GetLoggerByCtx(c)
+		logger.Ctx(c).Warn("database connection acquisition timeout", zap.Duration("timeout", c.GetDuration("acquire_timeout")))
+		utils.RespondError(c, http.StatusServiceUnavailable, utils.ErrCodeUnavailable, "database connection unavailable", nil)
+		c.Abort()
+		return false, nil
+	}
+	if tx.Error != nil {
+		logger := utils.GetLoggerByCtx(c)
+		logger.Ctx(c).Error("failed to begin transaction", zap.Error(tx.Error))
+		utils.RespondError(c, http.StatusInternalServerError, utils.ErrCodeDatabase, "failed to begin transaction", nil)
+		c.Abort()
+		return false, nil
+	}
+
+	// 将事务设置到上下文中，同时保存开启事务的实例，供分块提交时开启新事务
+	c.Set("tx", tx)
+	c.Set("tx_db", base)
+
+	// 捕获 panic，回滚事务
+	defer func() {
+		if r := recover(); r != nil {
+			c.MustGet("tx").(*gorm.DB).Rollback()
+			panic(r) // 继续抛出 panic
+		}
+	}()
+
+	// 执行下一个中间件或处理程序
+	next()
+
+	// 处理程序分块提交时会替换上下文中的事务，以最新的事务为准，之前的块已提交，不能重试
+	chunked := c.MustGet("tx").(*gorm.DB) != tx
+	tx = c.MustGet("tx").(*gorm.DB)
+
+	// 处理程序设置了 tx_rollback（如试运行）时回滚，不重试
+	if c.GetBool("tx_rollback") {
+		tx.Rollback()
+		return false, nil
+	}
+
+	// 根据响应状态提交或回滚事务，请求超时或取消时同样回滚
+	if len(c.Errors) > 0 || c.Request.Context().Err() != nil {
+		tx.Rollback()
+		return !chunked && retryableErrors(c.Errors), nil
+	}
+	if err := tx.Commit().Error; err != nil {
+		logger := utils.GetLoggerByCtx(c)
+		logger.Ctx(c).Error("failed to commit transaction", zap.Error(err))
+		tx.Rollback()
+		return !chunked && retryableError(err), err
+	}
+	return false, nil
+}
+
+// skipTransaction 判断请求是否无需开启事务
+func skipTransaction(c *gin.Context, skipPaths []string) bool {
+	if c.Request.Method == http.MethodOptions || c.FullPath() == "" {
+		return true
+	}
+	for _, prefix := range skipPaths {
+		if strings.HasPrefix(c.Request.URL.Path, prefix) {
+			return true
 		}
 	}
+	return false
 }
@@ -0,0 +1,67 @@
+package middlewares
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"minigo/utils"
+)
+
+// openDeferredDataBase 打开启用外键的 SQLite 数据库，children 表的外键约束延迟到提交时检查
+func openDeferredDataBase(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := "file:" + filepath.Join(t.TempDir(), "test.db") + "?_foreign_keys=on"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	for _, sql := range []string{
+		"CREATE TABLE parents (id INTEGER PRIMARY KEY)",
+		"CREATE TABLE children (id INTEGER PRIMARY KEY, parent_id INTEGER REFERENCES parents(id) DEFERRABLE INITIALLY DEFERRED)",
+	} {
+		if err := db.Exec(sql).Error; err != nil {
+			t.Fatalf("failed to create table: %v", err)
+		}
+	}
+	return db
+}
+
+func TestTransactionCommitFailure(t *testing.T) {
+	db := openDeferredDataBase(t)
+	r := gin.New()
+	r.Use(TransactionMiddleware(db))
+	r.POST("/children", func(c *gin.Context) {
+		// 外键约束延迟到提交时检查，处理程序中的写入成功，提交失败
+		tx := c.MustGet("tx").(*gorm.DB)
+		if err := tx.Exec("INSERT INTO children (parent_id) VALUES (1)").Error; err != nil {
+			t.Errorf("insert should succeed before commit: %v", err)
+		}
+		c.JSON(http.StatusCreated, gin.H{"result": "created by handler"})
+	})
+
+	w := serve(r, http.MethodPost, "/children")
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), utils.ErrCodeDatabase) {
+		t.Fatalf("commit failure response: %d %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "created by handler") {
+		t.Fatalf("handler body should be discarded: %s", w.Body.String())
+	}
+
+	var count int64
+	db.Table("children").Count(&count)
+	if count != 0 {
+		t.Fatalf("children = %d, want 0", count)
+	}
+}
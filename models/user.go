@@ -5,13 +5,16 @@ import (
 )
 
 // ctags自定义标签说明: q-查询字段, u-更新字段，o-排序字段，用于在列表和更新接口校验参数
+// required-创建时必填字段，encrypt-加密存储字段（不参与查询和搜索），wo-仅创建时可设置的字段（更新时拒绝）
+// c-创建时可提交的字段，声明了 c 字段的模型创建时忽略其余字段（wo 字段始终可创建），未声明时不限制
+// version-乐观锁版本字段（json 名为 version 的字段同样视为版本字段），更新时携带版本号且与记录不一致时返回 409
 type User struct {
 	BaseModel
 	DeletedAt soft_delete.DeletedAt `json:"-" gorm:"index:i_user_deleted_at;uniqueIndex:u_user_username;uniqueIndex:u_user_email;"`
 
-	Username string `json:"username" gorm:"type:varchar(64);index:i_user_username;uniqueIndex:u_user_username;" ctags:"username,q,u"`
+	Username string `json:"username" gorm:"type:varchar(64);index:i_user_username;uniqueIndex:u_user_username;" ctags:"username,q,c,u"`
 
-	Email string `json:"email" gorm:"type:varchar(64);index:i_user_email;uniqueIndex:u_user_email;" ctags:"email,q,u"`
+	Email string `json:"email" gorm:"type:varchar(64);index:i_user_email;uniqueIndex:u_user_email;" ctags:"email,q,c,u"`
 
-	Password string `json:"-" gorm:"type:varchar(256);" ctags:"password,u"`
+	Password string `json:"-" gorm:"type:varchar(256);" ctags:"password,c,u"`
 }
@@ -2,19 +2,29 @@ package utils
 
 import (
 	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 	"unicode"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
 )
 
 // GetDbByCtx 获取当前上下文中的事务或全局数据库实例
+// 上下文设置了查询超时（query_timeout）时，返回的实例绑定由请求上下文派生的超时上下文，超时后 SQL 被取消并返回错误
 func GetDbByCtx(c *gin.Context) *gorm.DB {
 	var db *gorm.DB
 
@@ -22,9 +32,34 @@ func GetDbByCtx(c *gin.Context) *gorm.DB {
 	if exists {
 		db = tx.(*gorm.DB)
 	}
+	if db == nil {
+		return db
+	}
+
+	if timeout := c.GetDuration("query_timeout"); timeout > 0 {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		// 请求结束时释放计时器
+		context.AfterFunc(c.Request.Context(), cancel)
+		db = db.WithContext(ctx)
+	}
 	return db
 }
 
+// FeatureEnabled 获取当前请求的功能开关，未设置时返回 fallback
+func FeatureEnabled(c *gin.Context, name string, fallback bool) bool {
+	features, exists := c.Get("features")
+	if !exists {
+		return fallback
+	}
+	if enabled, ok := features.(map[string]bool)[name]; ok {
+		return enabled
+	}
+	return fallback
+}
+
+// ErrUnsupportedMediaType 不支持的请求体类型
+var ErrUnsupportedMediaType = errors.New("unsupported Content-Type")
+
 // UnbindContext 解析请求体内容到 []map[string]interface{}
 func UnbindContext(c *gin.Context) ([]map[string]interface{}, error) {
 	results := make([]map[string]interface{}, 0)
@@ -38,11 +73,15 @@ func UnbindContext(c *gin.Context) ([]map[string]interface{}, error) {
 	// 重要：重新设置请求体，因为ReadAll会消耗body
 	c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
 
-	// 获取 Content-Type
+	// 获取 Content-Type，忽略 charset 等参数
 	contentType := c.GetHeader("Content-Type")
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedMediaType, contentType)
+	}
 
 	// 如果是 JSON 格式
-	if strings.HasPrefix(contentType, "application/json") {
+	if IsJSONMediaType(mediaType) {
 		var result interface{}
 		if err := json.Unmarshal(body, &result); err != nil {
 			return nil, fmt.Errorf("failed to parse json body: %v", err)
@@ -63,10 +102,9 @@ func UnbindContext(c *gin.Context) ([]map[string]interface{}, error) {
 		default:
 			return nil, fmt.Errorf("unexpected json type: %T", v)
 		}
-	} else if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") ||
-		strings.HasPrefix(contentType, "multipart/form-data") {
+	} else if mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data" {
 		// 对于 multipart/form-data，应该使用 ParseMultipartForm
-		if strings.HasPrefix(contentType, "multipart/form-data") {
+		if mediaType == "multipart/form-data" {
 			if err := c.Request.ParseMultipartForm(32 << 20); err != nil { // 32MB max
 				return nil, fmt.Errorf("failed to parse multipart form: %v", err)
 			}
@@ -106,13 +144,36 @@ func UnbindContext(c *gin.Context) ([]map[string]interface{}, error) {
 
 		results = append(results, formData)
 	} else {
-		return nil, fmt.Errorf("unsupported Content-Type: %s", contentType)
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedMediaType, contentType)
 	}
 
 	return results, nil
 }
 
-// BindContext 将 map[string]interface{} 数据绑定到结构体
+// IsJSONMediaType 判断是否为 JSON 媒体类型，包括 application/json 和 +json 后缀类型
+func IsJSONMediaType(mediaType string) bool {
+	mediaType = strings.ToLower(mediaType)
+	if mediaType == "application/json" {
+		return true
+	}
+	return strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json")
+}
+
+// BindError 字段值无法转换为字段类型时的绑定错误，请求体格式正确但字段值不合法
+type BindError struct {
+	Field string // 请求数据中的字段名
+	Err   error
+}
+
+func (e *BindError) Error() string {
+	return fmt.Sprintf("failed to set field %s: %v", e.Field, e.Err)
+}
+
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// BindContext 将 map[string]interface{} 数据绑定到结构体，字段值无法转换时返回 *BindError
 func BindContext(data map[string]interface{}, v interface{}) error {
 	// 获取指针指向的值
 	rv := reflect.ValueOf(v)
@@ -126,24 +187,30 @@ func BindContext(data map[string]interface{}, v interface{}) error {
 		return fmt.Errorf("invalid target type, expected struct, got %v", rv.Kind())
 	}
 
-	// 遍历结构体字段
-	rt := rv.Type()
-	for i := 0; i < rt.NumField(); i++ {
-		field := rt.Field(i)
-		fieldValue := rv.Field(i)
+	// 遍历结构体字段，匿名嵌入的结构体（含指针）展开后绑定
+	InitEmbeddedPointers(rv)
+	for _, field := range StructFields(rv.Type()) {
+		// 嵌入结构体中由 GORM 维护的主键和时间戳字段不参与绑定，与嵌入值类型 BaseModel 时的行为一致
+		if len(field.Index) > 1 && isManagedField(field) {
+			continue
+		}
 
 		// 跳过未导出的字段
-		if !fieldValue.CanSet() {
+		fieldValue, err := rv.FieldByIndexErr(field.Index)
+		if err != nil || !fieldValue.CanSet() {
 			continue
 		}
 
-		// 获取字段名（转为小写用于匹配）
-		fieldName := strings.ToLower(field.Name)
+		// 获取字段名，优先使用 json 标签，json:"-" 的字段使用 ctags 字段名（如只写的密码字段），均未声明时不参与绑定
+		fieldName := BindFieldName(field)
+		if fieldName == "" {
+			continue
+		}
 
 		// 查找对应的数据
 		if value, exists := data[fieldName]; exists && value != nil {
 			if err := setValue(fieldValue, value); err != nil {
-				return fmt.Errorf("failed to set field %s: %v", field.Name, err)
+				return &BindError{Field: fieldName, Err: err}
 			}
 		}
 	}
@@ -151,6 +218,277 @@ func BindContext(data map[string]interface{}, v interface{}) error {
 	return nil
 }
 
+// isManagedField 判断是否为 GORM 维护的主键或自动时间戳字段
+func isManagedField(field reflect.StructField) bool {
+	gormTag := strings.ToLower(field.Tag.Get("gorm"))
+	return strings.Contains(gormTag, "primarykey") || strings.Contains(gormTag, "primary_key") ||
+		strings.Contains(gormTag, "autocreatetime") || strings.Contains(gormTag, "autoupdatetime")
+}
+
+// JSONFieldName 获取字段在请求数据中的名称，优先使用 json 标签，其次为小写的字段名，json:"-" 时返回空字符串
+func JSONFieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
+
+// BindFieldName 获取字段在请求数据中可提交的名称，json:"-" 的字段不参与序列化，但声明了 ctags 时仍可按 ctags 字段名提交
+// 用于只写字段（如密码），与更新时按 ctags 字段名过滤的行为一致；均未声明时返回空字符串
+func BindFieldName(field reflect.StructField) string {
+	if name := JSONFieldName(field); name != "" {
+		return name
+	}
+	return strings.Split(field.Tag.Get("ctags"), ",")[0]
+}
+
+// GetPrimaryKeyType 获取模型主键类型，优先使用 gorm 标签声明的 primarykey 字段，其次为 ID 字段
+func GetPrimaryKeyType(modelType reflect.Type) reflect.Type {
+	for i := 0; i < modelType.NumField(); i++ {
+		field := modelType.Field(i)
+
+		// 查找嵌入结构体中的主键
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && fieldType.Kind() == reflect.Struct {
+			if pkType := GetPrimaryKeyType(fieldType); pkType != nil {
+				return pkType
+			}
+			continue
+		}
+
+		gormTag := strings.ToLower(field.Tag.Get("gorm"))
+		if strings.Contains(gormTag, "primarykey") || strings.Contains(gormTag, "primary_key") {
+			return field.Type
+		}
+	}
+
+	if field, ok := modelType.FieldByName("ID"); ok {
+		return field.Type
+	}
+	return nil
+}
+
+// ParsePrimaryKey 按模型主键类型转换请求中的主键值
+func ParsePrimaryKey(modelType reflect.Type, value interface{}) (interface{}, error) {
+	pkType := GetPrimaryKeyType(modelType)
+	if pkType == nil {
+		return value, nil
+	}
+
+	switch pkType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v, ok := ToInt64(value); ok {
+			return v, nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v, ok := ToUint64(value); ok {
+			return v, nil
+		}
+	case reflect.String:
+		switch v := value.(type) {
+		case string:
+			if v != "" {
+				return v, nil
+			}
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		}
+	default:
+		return value, nil
+	}
+	return nil, fmt.Errorf("invalid primary key: %v", value)
+}
+
+// IsRequiredField 判断字段创建时是否必填，必填字段为 gorm 标签声明 not null（且无默认值）或 ctags 标记 required 的字段
+func IsRequiredField(field reflect.StructField) bool {
+	ctag := field.Tag.Get("ctags")
+	if ctag != "" && ExistsIn(strings.Split(ctag, ",")[1:], "required") {
+		return true
+	}
+
+	gormTag := strings.ToLower(field.Tag.Get("gorm"))
+	return strings.Contains(gormTag, "not null") &&
+		!strings.Contains(gormTag, "default:") && !strings.Contains(gormTag, "primarykey") &&
+		!strings.Contains(gormTag, "autocreatetime") && !strings.Contains(gormTag, "autoupdatetime")
+}
+
+// ServerManagedFields 由服务端维护的字段，创建时始终忽略请求中的值
+var ServerManagedFields = []string{"id", "created_at", "updated_at", "deleted_at"}
+
+// StripServerManagedFields 删除请求数据中由服务端维护的字段，返回被删除的字段名
+func StripServerManagedFields(data map[string]interface{}) []string {
+	var stripped []string
+	for _, name := range ServerManagedFields {
+		if _, exists := data[name]; exists {
+			delete(data, name)
+			stripped = append(stripped, name)
+		}
+	}
+	return stripped
+}
+
+// CreatableFields 获取创建时允许提交的字段名（json 字段名，json:"-" 的字段为 ctags 字段名），ctags 含 c（可创建）或 wo（仅创建时可设置）的字段
+// 模型未声明任何 c 字段时返回 nil，表示不限制创建时提交的字段
+func CreatableFields(modelType reflect.Type) []string {
+	var declared bool
+	var fields []string
+
+	for _, field := range StructFields(modelType) {
+		tag := field.Tag.Get("ctags")
+		if tag == "" {
+			continue
+		}
+
+		fieldTags := strings.Split(tag, ",")[1:]
+		if ExistsIn(fieldTags, "c") {
+			declared = true
+		}
+		if !ExistsIn(fieldTags, "c") && !ExistsIn(fieldTags, "wo") {
+			continue
+		}
+		if fieldName := BindFieldName(field); fieldName != "" {
+			fields = append(fields, fieldName)
+		}
+	}
+
+	if !declared {
+		return nil
+	}
+	return fields
+}
+
+// VersionField 获取乐观锁版本字段，ctags 含 version 的字段或 json 名为 version 的字段
+func VersionField(modelType reflect.Type) (reflect.StructField, bool) {
+	for _, field := range StructFields(modelType) {
+		if field.Anonymous || !field.IsExported() {
+			continue
+		}
+		if ExistsIn(strings.Split(field.Tag.Get("ctags"), ",")[1:], "version") || JSONFieldName(field) == "version" {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// IsJSONColumn 判断字段是否以 JSON 存储：gorm 标签声明 serializer:json 或 type:json/jsonb，字段类型为 json.RawMessage 或 GORM 数据类型为 json/jsonb
+func IsJSONColumn(field reflect.StructField) bool {
+	settings := schema.ParseTagSetting(field.Tag.Get("gorm"), ";")
+	if strings.EqualFold(settings["SERIALIZER"], "json") {
+		return true
+	}
+	if dataType := strings.ToLower(settings["TYPE"]); dataType == "json" || dataType == "jsonb" {
+		return true
+	}
+
+	fieldType := field.Type
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	if fieldType == reflect.TypeOf(json.RawMessage{}) {
+		return true
+	}
+	if dataTyper, ok := reflect.New(fieldType).Interface().(schema.GormDataTypeInterface); ok {
+		dataType := strings.ToLower(dataTyper.GormDataType())
+		return dataType == "json" || dataType == "jsonb"
+	}
+	return false
+}
+
+// MarshalJSONUpdates 将更新字段中 JSON 列的值序列化为 JSON 字符串，updates 的键为 ctags 字段名
+// 按 map 更新时 GORM 不经过字段的序列化器，嵌套的对象和数组需先序列化才能按列的格式保存，null 保持为 NULL
+func MarshalJSONUpdates(modelType reflect.Type, updates map[string]interface{}) error {
+	for _, field := range StructFields(modelType) {
+		if field.Anonymous || !IsJSONColumn(field) {
+			continue
+		}
+		fieldName := strings.Split(field.Tag.Get("ctags"), ",")[0]
+		value, exists := updates[fieldName]
+		if fieldName == "" || !exists || value == nil {
+			continue
+		}
+
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to marshal field %s: %v", fieldName, err)
+		}
+		// 字节切片类型（如 json.RawMessage）的字段无法从字符串扫描，保持为字节
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.Uint8 {
+			updates[fieldName] = data
+		} else {
+			updates[fieldName] = string(data)
+		}
+	}
+	return nil
+}
+
+// MissingRequiredFields 检查创建时缺失的必填字段
+func MissingRequiredFields(modelType reflect.Type, data map[string]interface{}) []string {
+	var missing []string
+
+	for _, field := range StructFields(modelType) {
+		if field.Anonymous || !field.IsExported() {
+			continue
+		}
+
+		if !IsRequiredField(field) {
+			continue
+		}
+
+		// 请求数据中的字段名，json:"-" 且未声明 ctags 的字段无法通过请求提交
+		fieldName := BindFieldName(field)
+		if fieldName == "" {
+			continue
+		}
+
+		if value, exists := data[fieldName]; !exists || value == nil || value == "" {
+			missing = append(missing, fieldName)
+		}
+	}
+
+	return missing
+}
+
+// defaultNamer GORM 默认的命名策略，modelSchemas 缓存按默认命名策略解析的模型，不能用于其他命名策略
+var (
+	defaultNamer schema.Namer = schema.NamingStrategy{}
+	modelSchemas sync.Map
+)
+
+// defaultTableName 使用 GORM 的 schema.Parse 按默认命名策略解析表名，模型无法解析时按类型名转换
+func defaultTableName(modelPtr interface{}) string {
+	sch, err := schema.Parse(modelPtr, &modelSchemas, defaultNamer)
+	if err != nil {
+		return defaultNamer.TableName(reflect.TypeOf(modelPtr).Elem().Name())
+	}
+	return sch.Table
+}
+
+// TableNameOf 按数据库实例的命名策略（表前缀、单数表名等）解析模型的表名，与该实例执行查询时使用的表名一致
+// db 为 nil 时按 GORM 默认的命名策略解析
+func TableNameOf(db *gorm.DB, model interface{}) string {
+	_, modelPtr, tableName := GetModelInfo(model)
+	if db == nil {
+		return tableName
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(modelPtr); err != nil {
+		return db.NamingStrategy.TableName(reflect.TypeOf(modelPtr).Elem().Name())
+	}
+	return stmt.Schema.Table
+}
+
 // GetModelInfo 获取模型类型，指针，表名
 func GetModelInfo(model interface{}) (reflect.Type, interface{}, string) {
 	modelType := reflect.TypeOf(model)
@@ -160,46 +498,60 @@ func GetModelInfo(model interface{}) (reflect.Type, interface{}, string) {
 		modelType = modelType.Elem()
 	}
 
-	// 创建新的实例
-	modelPtr := reflect.New(modelType).Interface()
+	// 创建新的实例，初始化匿名嵌入的结构体指针，避免访问其中的字段时出现空指针
+	modelValue := reflect.New(modelType)
+	InitEmbeddedPointers(modelValue.Elem())
+	modelPtr := modelValue.Interface()
 
-	// 获取数据库表名
-	tableName := ""
-	// 1. 先尝试从 modelPtr 中获取表名
-	if tabler, ok := modelPtr.(interface{ TableName() string }); ok {
-		tableName = tabler.TableName()
-	} else {
-		// 2. 如果 modelPtr 没有实现 TableName 方法，从 modelType 获取
-		typeName := modelType.Name()
-		if modelType.Kind() == reflect.Ptr {
-			typeName = modelType.Elem().Name()
-		}
-		// 转换为蛇形命名
-		runes := []rune(typeName)
-		for i := 0; i < len(runes); i++ {
-			if i > 0 && unicode.IsUpper(runes[i]) {
-				tableName += "_"
-			}
-			tableName += strings.ToLower(string(runes[i]))
-		}
-		// 如果有数据库表配置，则添加前缀和后缀。
-		for _, instanceDB := range instanceDbs {
-			var skip = false
-			if instanceDB != nil && !instanceDB.config.SingularTable {
-				tableName += "s"
-				skip = true
-			}
-			if instanceDB != nil && instanceDB.config.TablePrefix != "" {
-				tableName = instanceDB.config.TablePrefix + tableName
-				skip = true
-			}
-			if skip {
-				break
+	// 获取数据库表名，按 GORM 默认的命名策略解析，与 GORM 的 TableName 方法、复数规则一致
+	// 配置了表前缀或单数表名的数据库使用 TableNameOf 按该数据库的命名策略解析
+	tableName := defaultTableName(modelPtr)
+
+	return modelType, modelPtr, tableName
+}
+
+// StructFields 获取结构体的字段，匿名嵌入的结构体和结构体指针会被展开（与 encoding/json 一致，指定了 json 名称的除外），
+// 展开的字段的 Index 为相对 modelType 的完整路径，可用于 reflect.Value.FieldByIndex
+func StructFields(modelType reflect.Type) []reflect.StructField {
+	fields := make([]reflect.StructField, 0, modelType.NumField())
+	for i := 0; i < modelType.NumField(); i++ {
+		field := modelType.Field(i)
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && fieldType.Kind() == reflect.Struct && strings.Split(field.Tag.Get("json"), ",")[0] == "" {
+			for _, embedded := range StructFields(fieldType) {
+				embedded.Index = append([]int{i}, embedded.Index...)
+				fields = append(fields, embedded)
 			}
+			continue
 		}
+		fields = append(fields, field)
 	}
+	return fields
+}
 
-	return modelType, modelPtr, tableName
+// InitEmbeddedPointers 初始化结构体中为 nil 的匿名嵌入结构体指针（如 *BaseModel），rv 为可设置的结构体值
+func InitEmbeddedPointers(rv reflect.Value) {
+	if rv.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Type().Field(i)
+		fieldValue := rv.Field(i)
+		if !field.Anonymous || !fieldValue.CanSet() {
+			continue
+		}
+		if fieldValue.Kind() == reflect.Ptr && fieldValue.Type().Elem().Kind() == reflect.Struct {
+			if fieldValue.IsNil() {
+				fieldValue.Set(reflect.New(fieldValue.Type().Elem()))
+			}
+			fieldValue = fieldValue.Elem()
+		}
+		InitEmbeddedPointers(fieldValue)
+	}
 }
 
 // Camel2Snake 驼峰转蛇形
@@ -226,6 +578,26 @@ func ExistsIn[T comparable](slice []T, item T) bool {
 	return false
 }
 
+// NewUUID 生成随机的 UUID（版本 4）
+func NewUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("failed to generate uuid: %v", err))
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // 版本 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 变体
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// GetMapKeys 获取 map 的所有键，顺序不固定
+func GetMapKeys[K comparable, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
 // 类型转换辅助函数
 func ToInt64(v interface{}) (int64, bool) {
 	switch val := v.(type) {
@@ -327,6 +699,22 @@ func ToBool(v interface{}) (bool, bool) {
 	return false, false
 }
 
+// ToTime 转换为时间，支持 RFC3339 字符串和毫秒时间戳
+func ToTime(v interface{}) (time.Time, bool) {
+	switch val := v.(type) {
+	case time.Time:
+		return val, true
+	case string:
+		if t, err := time.Parse(time.RFC3339, val); err == nil {
+			return t, true
+		}
+	}
+	if ms, ok := ToInt64(v); ok {
+		return time.UnixMilli(ms), true
+	}
+	return time.Time{}, false
+}
+
 // setValue 设置字段值
 func setValue(field reflect.Value, value interface{}) error {
 	val := reflect.ValueOf(value)
@@ -349,6 +737,21 @@ func setValue(field reflect.Value, value interface{}) error {
 		field = field.Elem()
 	}
 
+	// 时间类型按 RFC3339 或毫秒时间戳解析
+	if field.Type() == reflect.TypeOf(time.Time{}) {
+		v, ok := ToTime(value)
+		if !ok {
+			return fmt.Errorf("cannot convert %v to time", value)
+		}
+		field.Set(reflect.ValueOf(v))
+		return nil
+	}
+
+	// 实现了 encoding.TextUnmarshaler 或 sql.Scanner 的自定义类型使用其自身的解析方法
+	if handled, err := setCustomValue(field, value); handled {
+		return err
+	}
+
 	switch field.Kind() {
 	case reflect.String:
 		field.SetString(fmt.Sprint(value))
@@ -400,6 +803,41 @@ func setValue(field reflect.Value, value interface{}) error {
 	return nil
 }
 
+// setCustomValue 通过字段类型实现的接口设置值，返回是否已处理
+// 字符串优先使用 encoding.TextUnmarshaler，其他值使用 sql.Scanner
+func setCustomValue(field reflect.Value, value interface{}) (bool, error) {
+	if !field.CanAddr() {
+		return false, nil
+	}
+	target := field.Addr().Interface()
+
+	if str, ok := value.(string); ok {
+		if unmarshaler, ok := target.(encoding.TextUnmarshaler); ok {
+			return true, unmarshaler.UnmarshalText([]byte(str))
+		}
+	}
+
+	scanner, ok := target.(sql.Scanner)
+	if !ok {
+		return false, nil
+	}
+
+	// 转换为数据库驱动返回的类型：整数值的 float64 转为 int64，map 和切片转为 JSON 字节
+	switch v := value.(type) {
+	case float64:
+		if v == float64(int64(v)) {
+			value = int64(v)
+		}
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return true, err
+		}
+		value = data
+	}
+	return true, scanner.Scan(value)
+}
+
 func setSlice(field reflect.Value, value interface{}) error {
 	val := reflect.ValueOf(value)
 	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUnbindContextContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		unsupported bool
+	}{
+		{"application/json", false},
+		{"application/json; charset=utf-8", false},
+		{"Application/JSON", false},
+		{"application/merge-patch+json", false},
+		{"application/vnd.api+json; charset=utf-8", false},
+		{"application/jsonx", true},
+		{"application/json-seq", true},
+		{"text/json", true},
+		{"", true},
+	}
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"item"}`))
+		c.Request.Header.Set("Content-Type", tt.contentType)
+
+		results, err := UnbindContext(c)
+		if tt.unsupported {
+			if !errors.Is(err, ErrUnsupportedMediaType) {
+				t.Errorf("%q: err = %v, want unsupported media type", tt.contentType, err)
+			}
+			continue
+		}
+		if err != nil || len(results) != 1 || results[0]["name"] != "item" {
+			t.Errorf("%q: results = %v, err = %v", tt.contentType, results, err)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// encryptedPrefix 密文前缀，用于区分历史明文数据
+const encryptedPrefix = "enc:"
+
+// Cipher 字段加解密接口，可替换为自定义实现
+type Cipher interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(ciphertext string) (string, error)
+}
+
+// AESGCMCipher 基于 AES-GCM 的字段加密实现
+type AESGCMCipher struct {
+	aead cipher.AEAD
+}
+
+// NewAESGCMCipher 创建 AES-GCM 加密器，key 长度须为 16、24 或 32 字节
+func NewAESGCMCipher(key []byte) (*AESGCMCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %v", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %v", err)
+	}
+	return &AESGCMCipher{aead: aead}, nil
+}
+
+// Encrypt 加密明文，输出形如 enc:<base64(nonce+密文)>
+func (a *AESGCMCipher) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, a.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %v", err)
+	}
+	sealed := a.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密密文，不带密文前缀的值视为明文原样返回
+func (a *AESGCMCipher) Decrypt(ciphertext string) (string, error) {
+	if !strings.HasPrefix(ciphertext, encryptedPrefix) {
+		return ciphertext, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(ciphertext, encryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode ciphertext: %v", err)
+	}
+	nonceSize := a.aead.NonceSize()
+	if len(data) < nonceSize {
+		return "", fmt.Errorf("ciphertext too short")
+	}
+	plaintext, err := a.aead.Open(nil, data[:nonceSize], data[nonceSize:], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt ciphertext: %v", err)
+	}
+	return string(plaintext), nil
+}
+
+var (
+	fieldCipher   Cipher
+	muFieldCipher sync.RWMutex
+)
+
+// SetFieldCipher 设置字段加密器
+func SetFieldCipher(c Cipher) {
+	muFieldCipher.Lock()
+	defer muFieldCipher.Unlock()
+	fieldCipher = c
+}
+
+// GetFieldCipher 获取字段加密器
+func GetFieldCipher() Cipher {
+	muFieldCipher.RLock()
+	defer muFieldCipher.RUnlock()
+	return fieldCipher
+}
+
+// IsEncryptedField 判断字段是否通过 ctags 标记了 encrypt
+func IsEncryptedField(field reflect.StructField) bool {
+	tag := field.Tag.Get("ctags")
+	if tag == "" {
+		return false
+	}
+	return ExistsIn(strings.Split(tag, ",")[1:], "encrypt")
+}
+
+// EncryptFields 加密结构体中标记了 encrypt 的字符串字段，v 为结构体指针
+func EncryptFields(v interface{}) error {
+	return transformEncryptedFields(reflect.ValueOf(v), func(c Cipher, s string) (string, error) {
+		return c.Encrypt(s)
+	})
+}
+
+// DecryptFields 解密标记了 encrypt 的字符串字段，v 为结构体指针或结构体切片
+func DecryptFields(v interface{}) error {
+	return transformEncryptedFields(reflect.ValueOf(v), func(c Cipher, s string) (string, error) {
+		return c.Decrypt(s)
+	})
+}
+
+// EncryptUpdates 加密更新字段中标记了 encrypt 的值，updates 的键为 ctags 字段名
+func EncryptUpdates(modelType reflect.Type, updates map[string]interface{}) error {
+	for _, field := range StructFields(modelType) {
+		if !IsEncryptedField(field) {
+			continue
+		}
+		fieldName := strings.Split(field.Tag.Get("ctags"), ",")[0]
+		value, exists := updates[fieldName]
+		if !exists || value == nil {
+			continue
+		}
+
+		c := GetFieldCipher()
+		if c == nil {
+			return fmt.Errorf("no cipher configured for encrypted field %s", fieldName)
+		}
+		encrypted, err := c.Encrypt(fmt.Sprint(value))
+		if err != nil {
+			return err
+		}
+		updates[fieldName] = encrypted
+	}
+	return nil
+}
+
+// transformEncryptedFields 遍历结构体（或切片中的结构体）的加密字段并转换
+func transformEncryptedFields(rv reflect.Value, fn func(Cipher, string) (string, error)) error {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if err := transformEncryptedFields(rv.Index(i), fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		for _, field := range StructFields(rv.Type()) {
+			// 匿名嵌入的结构体指针为 nil 时跳过其中的字段
+			fieldValue, err := rv.FieldByIndexErr(field.Index)
+			if err != nil || !IsEncryptedField(field) || fieldValue.Kind() != reflect.String || !fieldValue.CanSet() {
+				continue
+			}
+			if fieldValue.String() == "" {
+				continue
+			}
+
+			c := GetFieldCipher()
+			if c == nil {
+				return fmt.Errorf("no cipher configured for encrypted field %s", field.Name)
+			}
+			value, err := fn(c, fieldValue.String())
+			if err != nil {
+				return fmt.Errorf("failed to transform field %s: %v", field.Name, err)
+			}
+			fieldValue.SetString(value)
+		}
+	}
+	return nil
+}
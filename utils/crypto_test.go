@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAESGCMCipher(t *testing.T) {
+	c, err := NewAESGCMCipher([]byte("0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("failed to create cipher: %v", err)
+	}
+
+	ciphertext, err := c.Encrypt("secret")
+	if err != nil {
+		t.Fatalf("failed to encrypt: %v", err)
+	}
+	if !strings.HasPrefix(ciphertext, encryptedPrefix) || strings.Contains(ciphertext, "secret") {
+		t.Fatalf("ciphertext = %q", ciphertext)
+	}
+	// 每次加密使用随机 nonce，相同明文的密文不同
+	if again, _ := c.Encrypt("secret"); again == ciphertext {
+		t.Fatalf("ciphertext should differ between encryptions")
+	}
+
+	if plaintext, err := c.Decrypt(ciphertext); err != nil || plaintext != "secret" {
+		t.Fatalf("decrypt = %q, %v", plaintext, err)
+	}
+	// 不带前缀的历史明文原样返回
+	if plaintext, err := c.Decrypt("legacy"); err != nil || plaintext != "legacy" {
+		t.Fatalf("decrypt legacy = %q, %v", plaintext, err)
+	}
+
+	other, _ := NewAESGCMCipher([]byte("fedcba9876543210"))
+	if _, err := other.Decrypt(ciphertext); err == nil {
+		t.Fatalf("decrypt with another key should fail")
+	}
+	if _, err := NewAESGCMCipher([]byte("short")); err == nil {
+		t.Fatalf("invalid key length should fail")
+	}
+}
@@ -2,12 +2,18 @@ package utils
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"net"
+	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 	"gorm.io/driver/mysql"
@@ -16,6 +22,7 @@ import (
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 	"gorm.io/gorm/schema"
+	"gorm.io/plugin/dbresolver"
 )
 
 // DBType 数据库类型
@@ -31,27 +38,54 @@ const (
 
 // DBConfig 数据库配置结构体
 type DBConfig struct {
-	Type            DBType        `mapstructure:"type"`            // 数据库类型
-	Host            string        `mapstructure:"host"`            // 主机地址
-	Port            int           `mapstructure:"port"`            // 端口
-	Username        string        `mapstructure:"username"`        // 用户名
-	Password        string        `mapstructure:"password"`        // 密码
-	Database        string        `mapstructure:"database"`        // 数据库名
-	Charset         string        `mapstructure:"charset"`         // 字符集
-	MaxIdleConns    int           `mapstructure:"maxIdleConns"`    // 最大空闲连接数
-	MaxOpenConns    int           `mapstructure:"maxOpenConns"`    // 最大打开连接数
-	ConnMaxLifetime int           `mapstructure:"connMaxLifetime"` // 连接最大生命周期（秒）
-	ConnMaxIdleTime int           `mapstructure:"connMaxIdleTime"` // 空闲连接最大生命周期（秒）
-	SingularTable   bool          `mapstructure:"singularTable"`   // 是否使用单数表名
-	TablePrefix     string        `mapstructure:"tablePrefix"`     // 表前缀
-	SlowThreshold   int           `mapstructure:"slowThreshold"`   // 慢查询阈值（毫秒）
-	LogLevel        string        `mapstructure:"logLevel"`        // 日志级别
-	SQLite          *SQLiteConfig `mapstructure:"sqlite"`          // SQLite特定配置
+	Type            DBType          `mapstructure:"type"`            // 数据库类型
+	Host            string          `mapstructure:"host"`            // 主机地址
+	Port            int             `mapstructure:"port"`            // 端口
+	Username        string          `mapstructure:"username"`        // 用户名
+	Password        string          `mapstructure:"password"`        // 密码
+	Database        string          `mapstructure:"database"`        // 数据库名
+	Charset         string          `mapstructure:"charset"`         // 字符集
+	MaxIdleConns    int             `mapstructure:"maxIdleConns"`    // 最大空闲连接数
+	MaxOpenConns    int             `mapstructure:"maxOpenConns"`    // 最大打开连接数
+	ConnMaxLifetime int             `mapstructure:"connMaxLifetime"` // 连接最大生命周期（秒）
+	ConnMaxIdleTime int             `mapstructure:"connMaxIdleTime"` // 空闲连接最大生命周期（秒）
+	SingularTable   bool            `mapstructure:"singularTable"`   // 是否使用单数表名
+	TablePrefix     string          `mapstructure:"tablePrefix"`     // 表前缀
+	SlowThreshold   int             `mapstructure:"slowThreshold"`   // 慢查询阈值（毫秒）
+	LogLevel        string          `mapstructure:"logLevel"`        // 日志级别
+	ApplicationName string          `mapstructure:"applicationName"` // 连接标识，便于DBA区分连接来源
+	EncryptKey      string          `mapstructure:"encryptKey"`      // 字段加密密钥（16/24/32字节），用于 ctags 标记 encrypt 的字段
+	AutoIncrement   AutoIncrement   `mapstructure:"autoIncrement"`   // 自增主键生成方式
+	Replicas        []string        `mapstructure:"replicas"`        // 只读副本连接串，配置后查询走副本，写操作和写事务走主库
+	ConnectRetries  int             `mapstructure:"connectRetries"`  // 启动时连接数据库的最大尝试次数
+	ConnectBackoff  int             `mapstructure:"connectBackoff"`  // 连接失败后首次重试的等待时间（毫秒），之后每次翻倍
+	SQLite          *SQLiteConfig   `mapstructure:"sqlite"`          // SQLite特定配置
+	Postgres        *PostgresConfig `mapstructure:"postgres"`        // PostgreSQL特定配置
+}
+
+// AutoIncrement 自增主键生成方式
+// 分库部署时各实例使用相同步长、不同起始值交错生成主键，主键不连续但保持递增，
+// 游标分页、排序等依赖主键的功能只要求主键有序，不受步长影响
+type AutoIncrement struct {
+	Offset    int `mapstructure:"offset"`    // 起始值
+	Increment int `mapstructure:"increment"` // 步长
 }
 
 // SQLiteConfig SQLite特定配置
+// 日志模式等参数通过连接串传给驱动，连接池中的每个连接打开时都会设置，为空或为 0 时使用 SQLite 的默认值
 type SQLiteConfig struct {
-	File string `mapstructure:"file"` // 数据库文件路径
+	File        string `mapstructure:"file"`        // 数据库文件路径
+	JournalMode string `mapstructure:"journalMode"` // 日志模式，WAL 模式下读写互不阻塞
+	Synchronous string `mapstructure:"synchronous"` // 同步级别，如 NORMAL、FULL
+	ForeignKeys bool   `mapstructure:"foreignKeys"` // 是否启用外键约束
+	BusyTimeout int    `mapstructure:"busyTimeout"` // 数据库被锁定时的等待时间（毫秒），减少并发事务的 "database is locked" 错误
+}
+
+// PostgresConfig PostgreSQL特定配置
+type PostgresConfig struct {
+	SSLMode    string `mapstructure:"sslMode"`    // SSL 模式，如 disable、require、verify-full
+	SearchPath string `mapstructure:"searchPath"` // 模式搜索路径，为空时使用服务端默认值（通常为 public）
+	TimeZone   string `mapstructure:"timeZone"`   // 会话时区
 }
 
 // Database 数据库结构体
@@ -79,24 +113,61 @@ var defaultDBConfig = DBConfig{
 	SingularTable:   false,
 	SlowThreshold:   200,
 	LogLevel:        "info",
+	ApplicationName: "minigo",
+	ConnectRetries:  1,
+	ConnectBackoff:  500,
+	AutoIncrement: AutoIncrement{
+		Offset:    1,
+		Increment: 1,
+	},
 	SQLite: &SQLiteConfig{
-		File: "data.db",
+		File:        "data.db",
+		JournalMode: "WAL",
+		Synchronous: "NORMAL",
+		BusyTimeout: 5000,
+	},
+	Postgres: &PostgresConfig{
+		SSLMode:  "disable",
+		TimeZone: "Asia/Shanghai",
 	},
 }
 
+// newDefaultDBConfig 复制一份默认配置，指针字段同样复制，修改返回值不影响默认配置
+func newDefaultDBConfig() *DBConfig {
+	config := defaultDBConfig
+	if defaultDBConfig.SQLite != nil {
+		sqliteConfig := *defaultDBConfig.SQLite
+		config.SQLite = &sqliteConfig
+	}
+	if defaultDBConfig.Postgres != nil {
+		postgresConfig := *defaultDBConfig.Postgres
+		config.Postgres = &postgresConfig
+	}
+	return &config
+}
+
 var (
 	instanceDbs = make(map[string]*Database)
 	muDB        sync.RWMutex
 )
 
-// GetDataBase 获取数据库实例
+// GetDataBase 获取数据库实例，初始化失败时 panic，需要处理错误时使用 OpenDataBase
 func GetDataBase(args ...string) *Database {
+	db, err := OpenDataBase(args...)
+	if err != nil {
+		panic(err.Error())
+	}
+	return db
+}
+
+// OpenDataBase 获取数据库实例，相同参数返回同一实例，初始化失败时返回错误
+func OpenDataBase(args ...string) (*Database, error) {
 	key := strings.Join(args, ":")
 
 	muDB.RLock()
 	if db, exists := instanceDbs[key]; exists {
 		muDB.RUnlock()
-		return db
+		return db, nil
 	}
 	muDB.RUnlock()
 
@@ -105,7 +176,7 @@ func GetDataBase(args ...string) *Database {
 
 	// 双重检查
 	if db, exists := instanceDbs[key]; exists {
-		return db
+		return db, nil
 	}
 
 	var config *DBConfig
@@ -115,31 +186,27 @@ func GetDataBase(args ...string) *Database {
 	switch len(args) {
 	case 1:
 		// 使用默认配置 + DSN
-		config = &defaultDBConfig
-		dsn = args[0]
-		if strings.Contains(dsn, "mysql") || strings.Contains(dsn, "@tcp(") {
-			config.Type = MySQL
-		} else if strings.Contains(dsn, "host=") && strings.Contains(dsn, "user=") && strings.Contains(dsn, "dbname=") {
-			config.Type = PostgreSQL
-		} else if strings.HasSuffix(dsn, ".db") || strings.HasSuffix(dsn, ".sqlite") || strings.Contains(dsn, "sqlite") {
-			config.Type = SQLite
-		} else {
-			panic("unsupported database type")
+		var dbType DBType
+		dbType, dsn, err = parseDSN(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize database: %v", err)
 		}
+		config = newDefaultDBConfig()
+		config.Type = dbType
 	case 2:
 		// 使用配置文件，默认段
 		config, err = loadDBConfig(args[0], "database")
 		if err != nil {
-			panic(fmt.Sprintf("failed to initialize database: %v", err))
+			return nil, fmt.Errorf("failed to initialize database: %v", err)
 		}
 	case 3:
 		// 使用配置文件，指定段
 		config, err = loadDBConfig(args[0], args[1])
 		if err != nil {
-			panic(fmt.Sprintf("failed to initialize database: %v", err))
+			return nil, fmt.Errorf("failed to initialize database: %v", err)
 		}
 	default:
-		panic("invalid parameters: GetDB(dsn) or GetDB(configFile, section)")
+		return nil, fmt.Errorf("invalid parameters: GetDB(dsn) or GetDB(configFile, section)")
 	}
 
 	db := &Database{
@@ -147,12 +214,96 @@ func GetDataBase(args ...string) *Database {
 		dsn:    dsn,
 	}
 	if err := db.initDB(); err != nil {
-		panic(fmt.Sprintf("failed to initialize database: %v", err))
+		return nil, fmt.Errorf("failed to initialize database: %v", err)
 	}
 
 	instanceDbs[key] = db
 
-	return db
+	return db, nil
+}
+
+// RegisterDataBase 以名称注册数据库实例，之后可通过 GetDataBase(name) 或 LookupDataBase(name) 获取，
+// 用于多租户或分库场景下将资源绑定到不同的数据库（见 controllers.WithDatabase）
+func RegisterDataBase(name string, db *Database) error {
+	if name == "" || db == nil {
+		return fmt.Errorf("invalid parameters: RegisterDataBase(name, db)")
+	}
+
+	muDB.Lock()
+	defer muDB.Unlock()
+
+	if existing, exists := instanceDbs[name]; exists && existing != db {
+		return fmt.Errorf("database %s already registered", name)
+	}
+	instanceDbs[name] = db
+	return nil
+}
+
+// LookupDataBase 按名称获取已注册的数据库实例，不会创建新实例
+func LookupDataBase(name string) (*Database, bool) {
+	muDB.RLock()
+	defer muDB.RUnlock()
+
+	db, exists := instanceDbs[name]
+	return db, exists
+}
+
+// parseDSN 根据连接串识别数据库类型，返回数据库类型和驱动可直接使用的连接串
+// 支持 URL 风格（mysql://、postgres://、postgresql://、sqlite://、file:）和各驱动的原生格式
+func parseDSN(dsn string) (DBType, string, error) {
+	lower := strings.ToLower(dsn)
+	switch {
+	case strings.HasPrefix(lower, "mysql://"):
+		converted, err := mysqlURLToDSN(dsn[len("mysql://"):])
+		return MySQL, converted, err
+	case strings.HasPrefix(lower, "postgres://"), strings.HasPrefix(lower, "postgresql://"):
+		// pgx 直接支持 URL 格式
+		return PostgreSQL, dsn, nil
+	case strings.HasPrefix(lower, "sqlite://"):
+		return SQLite, dsn[len("sqlite://"):], nil
+	case strings.HasPrefix(lower, "file:"):
+		// go-sqlite3 直接支持 file: URI
+		return SQLite, dsn, nil
+	case strings.Contains(dsn, "@tcp(") || strings.Contains(dsn, "@unix("):
+		return MySQL, dsn, nil
+	case strings.Contains(dsn, "host=") && strings.Contains(dsn, "user=") && strings.Contains(dsn, "dbname="):
+		return PostgreSQL, dsn, nil
+	case strings.HasSuffix(lower, ".db") || strings.HasSuffix(lower, ".sqlite") || strings.HasSuffix(lower, ".sqlite3") || lower == ":memory:":
+		return SQLite, dsn, nil
+	}
+	// 连接串可能包含密码，错误信息中不输出
+	return "", "", fmt.Errorf("unrecognized dsn format")
+}
+
+// mysqlURLToDSN 将去掉 mysql:// 前缀的 URL 转换为 go-sql-driver 格式，形如 user:password@tcp(host:port)/dbname?params
+func mysqlURLToDSN(rest string) (string, error) {
+	// 已是驱动格式
+	if strings.Contains(rest, "@tcp(") || strings.Contains(rest, "@unix(") {
+		return rest, nil
+	}
+
+	u, err := url.Parse("mysql://" + rest)
+	if err != nil {
+		return "", fmt.Errorf("invalid mysql url")
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "3306")
+	}
+
+	dsn := fmt.Sprintf("tcp(%s)/%s", host, strings.TrimPrefix(u.Path, "/"))
+	if u.User != nil {
+		// 驱动格式的用户名和密码不做转义
+		userinfo := u.User.Username()
+		if password, ok := u.User.Password(); ok {
+			userinfo += ":" + password
+		}
+		dsn = userinfo + "@" + dsn
+	}
+	if u.RawQuery != "" {
+		dsn += "?" + u.RawQuery
+	}
+	return dsn, nil
 }
 
 // SetLogger 设置自定义logger
@@ -171,7 +322,7 @@ func (d *Database) SetLogger(logger *Logger) *Database {
 
 // loadDBConfig 加载数据库配置
 func loadDBConfig(configPath, configSection string) (*DBConfig, error) {
-	config := defaultDBConfig
+	config := newDefaultDBConfig()
 
 	v := viper.New()
 	v.SetConfigFile(configPath)
@@ -186,16 +337,16 @@ func loadDBConfig(configPath, configSection string) (*DBConfig, error) {
 		if subConfig == nil {
 			return nil, fmt.Errorf("configuration section does not exist: %s", configSection)
 		}
-		if err := subConfig.Unmarshal(&config); err != nil {
+		if err := subConfig.Unmarshal(config); err != nil {
 			return nil, fmt.Errorf("failed to parse configuration file: %v", err)
 		}
 	} else {
-		if err := v.Unmarshal(&config); err != nil {
+		if err := v.Unmarshal(config); err != nil {
 			return nil, fmt.Errorf("failed to parse configuration file: %v", err)
 		}
 	}
 
-	return &config, nil
+	return config, nil
 }
 
 // CustomGormLogger GORM日志适配器
@@ -294,16 +445,13 @@ func getGormLogLevel(level string) logger.LogLevel {
 	}
 }
 
-// initDB 初始化数据库连接
-func (d *Database) initDB() error {
-	var dialector gorm.Dialector
-
+// buildDSN 根据配置生成连接串，并附加连接标识参数
+func (d *Database) buildDSN() (string, error) {
 	switch d.config.Type {
 	case MySQL, MariaDB, TiDB:
-		if d.dsn != "" {
-			dialector = mysql.Open(d.dsn)
-		} else {
-			dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=True&loc=Local",
+		dsn := d.dsn
+		if dsn == "" {
+			dsn = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=True&loc=Local",
 				d.config.Username,
 				d.config.Password,
 				d.config.Host,
@@ -311,32 +459,157 @@ func (d *Database) initDB() error {
 				d.config.Database,
 				d.config.Charset,
 			)
-			dialector = mysql.Open(dsn)
 		}
+		if d.config.ApplicationName != "" && !strings.Contains(dsn, "connectionAttributes=") {
+			// MySQL 通过连接属性上报程序名，可在 performance_schema.session_connect_attrs 中查看
+			dsn = appendDSNQuery(dsn, "connectionAttributes=program_name:"+d.config.ApplicationName)
+		}
+		if inc := d.config.AutoIncrement; inc.Increment > 1 && !strings.Contains(dsn, "auto_increment_increment=") {
+			// MySQL 驱动将未识别的参数作为会话变量设置，使当前连接按配置的起始值和步长生成主键
+			dsn = appendDSNQuery(dsn, fmt.Sprintf("auto_increment_increment=%d&auto_increment_offset=%d", inc.Increment, max(inc.Offset, 1)))
+		}
+		return dsn, nil
 
 	case PostgreSQL:
-		if d.dsn != "" {
-			dialector = postgres.Open(d.dsn)
-		} else {
-			dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable TimeZone=Asia/Shanghai",
+		dsn := d.dsn
+		if dsn == "" {
+			dsn = fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s",
 				d.config.Host,
 				d.config.Port,
 				d.config.Username,
 				d.config.Password,
 				d.config.Database,
 			)
-			dialector = postgres.Open(dsn)
+			if d.config.Postgres != nil {
+				dsn = appendPostgresParam(dsn, "sslmode", d.config.Postgres.SSLMode)
+				dsn = appendPostgresParam(dsn, "TimeZone", d.config.Postgres.TimeZone)
+			}
 		}
+		// 指定模式搜索路径，连接串中已指定时保持不变
+		if d.config.Postgres != nil {
+			dsn = appendPostgresParam(dsn, "search_path", d.config.Postgres.SearchPath)
+		}
+		// PostgreSQL 通过 application_name 标识连接，可在 pg_stat_activity 中查看
+		dsn = appendPostgresParam(dsn, "application_name", d.config.ApplicationName)
+		return dsn, nil
 
 	case SQLite:
+		// SQLite 为本地文件，无连接标识
+		dsn := d.config.SQLite.File
 		if d.dsn != "" {
-			dialector = sqlite.Open(d.dsn)
-		} else {
-			dialector = sqlite.Open(d.config.SQLite.File)
+			dsn = d.dsn
 		}
+		return d.config.SQLite.appendPragmas(dsn), nil
+
+	default:
+		return "", fmt.Errorf("unspported database type: %s", d.config.Type)
+	}
+}
+
+// appendPostgresParam 向 PostgreSQL 连接串追加参数，支持 URL 和 key=value 两种格式，值为空或连接串中已指定时保持不变
+func appendPostgresParam(dsn, key, value string) string {
+	if value == "" || strings.Contains(dsn, key+"=") {
+		return dsn
+	}
+	if strings.Contains(dsn, "://") {
+		return appendDSNQuery(dsn, key+"="+url.QueryEscape(value))
+	}
+	// key=value 格式中包含空格或引号的值需要加单引号
+	if strings.ContainsAny(value, ` '\`) {
+		value = "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+	}
+	return dsn + " " + key + "=" + value
+}
+
+// appendPragmas 将 PRAGMA 设置以驱动参数的形式追加到连接串，连接串中已指定的参数保持不变
+func (c *SQLiteConfig) appendPragmas(dsn string) string {
+	pragmas := [][2]string{
+		{"_journal_mode", c.JournalMode},
+		{"_synchronous", c.Synchronous},
+	}
+	if c.BusyTimeout > 0 {
+		pragmas = append(pragmas, [2]string{"_busy_timeout", strconv.Itoa(c.BusyTimeout)})
+	}
+	if c.ForeignKeys {
+		pragmas = append(pragmas, [2]string{"_foreign_keys", "1"})
+	}
+
+	for _, pragma := range pragmas {
+		if pragma[1] == "" || strings.Contains(dsn, pragma[0]+"=") {
+			continue
+		}
+		dsn = appendDSNQuery(dsn, pragma[0]+"="+pragma[1])
+	}
+	return dsn
+}
+
+// appendDSNQuery 向 URL 风格的连接串追加查询参数
+func appendDSNQuery(dsn, param string) string {
+	if strings.Contains(dsn, "?") {
+		return dsn + "&" + param
+	}
+	return dsn + "?" + param
+}
+
+// Primary 获取固定使用主库的数据库实例，配置了只读副本时用于迁移等需要读取主库结构的操作
+func (d *Database) Primary() *gorm.DB {
+	return d.DB.Clauses(dbresolver.Write)
+}
 
+// openDialector 按数据库类型创建连接驱动
+func (d *Database) openDialector(dsn string) gorm.Dialector {
+	switch d.config.Type {
+	case MySQL, MariaDB, TiDB:
+		return mysql.Open(dsn)
+	case PostgreSQL:
+		return postgres.Open(dsn)
 	default:
-		return fmt.Errorf("unspported database type: %s", d.config.Type)
+		return sqlite.Open(dsn)
+	}
+}
+
+// maxConnectBackoff 连接重试的最长等待时间
+const maxConnectBackoff = 30 * time.Second
+
+// connect 连接数据库并确认可用，失败时按指数退避重试，用于容器编排中数据库晚于应用启动的场景
+// 尝试次数用尽后返回最后一次的错误
+func (d *Database) connect(dsn string, gormConfig *gorm.Config) (*gorm.DB, *sql.DB, error) {
+	attempts := max(d.config.ConnectRetries, 1)
+	backoff := time.Duration(d.config.ConnectBackoff) * time.Millisecond
+
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		db, err := gorm.Open(d.openDialector(dsn), gormConfig)
+		if err == nil {
+			var sqlDB *sql.DB
+			if sqlDB, err = db.DB(); err == nil {
+				if err = sqlDB.Ping(); err == nil {
+					return db, sqlDB, nil
+				}
+				sqlDB.Close()
+			}
+		}
+		lastErr = err
+
+		if attempt < attempts {
+			GetLogger().Warn("failed to connect database, retrying",
+				zap.Int("attempt", attempt),
+				zap.Int("attempts", attempts),
+				zap.Duration("backoff", backoff),
+				zap.Error(err),
+			)
+			time.Sleep(backoff)
+			backoff = min(backoff*2, maxConnectBackoff)
+		}
+	}
+	return nil, nil, fmt.Errorf("failed to connect database after %d attempts: %v", attempts, lastErr)
+}
+
+// initDB 初始化数据库连接
+func (d *Database) initDB() error {
+	dsn, err := d.buildDSN()
+	if err != nil {
+		return err
 	}
 
 	gormConfig := &gorm.Config{
@@ -347,14 +620,14 @@ func (d *Database) initDB() error {
 		Logger: logger.Default.LogMode(getGormLogLevel(d.config.LogLevel)),
 	}
 
-	db, err := gorm.Open(dialector, gormConfig)
+	db, sqlDB, err := d.connect(dsn, gormConfig)
 	if err != nil {
-		return fmt.Errorf("failed to connect database: %v", err)
+		return err
 	}
 
-	sqlDB, err := db.DB()
-	if err != nil {
-		return fmt.Errorf("failed to connect database: %v", err)
+	// 统计 SQL 语句数，供批量接口报告实际执行的语句数
+	if err := registerStatementCounter(db); err != nil {
+		return err
 	}
 
 	// 设置连接池
@@ -363,6 +636,34 @@ func (d *Database) initDB() error {
 	sqlDB.SetConnMaxLifetime(time.Duration(d.config.ConnMaxLifetime) * time.Second)
 	sqlDB.SetConnMaxIdleTime(time.Duration(d.config.ConnMaxIdleTime) * time.Second)
 
+	// 读写分离，未指定时查询走只读副本，写操作和事务中的语句走主库
+	if len(d.config.Replicas) > 0 {
+		replicas := make([]gorm.Dialector, 0, len(d.config.Replicas))
+		for _, replicaDSN := range d.config.Replicas {
+			replicas = append(replicas, d.openDialector(replicaDSN))
+		}
+		resolver := dbresolver.Register(dbresolver.Config{
+			Replicas: replicas,
+			Policy:   dbresolver.RandomPolicy{},
+		}).
+			SetMaxIdleConns(d.config.MaxIdleConns).
+			SetMaxOpenConns(d.config.MaxOpenConns).
+			SetConnMaxLifetime(time.Duration(d.config.ConnMaxLifetime) * time.Second).
+			SetConnMaxIdleTime(time.Duration(d.config.ConnMaxIdleTime) * time.Second)
+		if err := db.Use(resolver); err != nil {
+			return fmt.Errorf("failed to register replicas: %v", err)
+		}
+	}
+
+	// 配置字段加密密钥
+	if d.config.EncryptKey != "" {
+		fc, err := NewAESGCMCipher([]byte(d.config.EncryptKey))
+		if err != nil {
+			return err
+		}
+		SetFieldCipher(fc)
+	}
+
 	d.DB = db
 	return nil
 }
@@ -391,6 +692,17 @@ func (d *Database) Close() error {
 	return nil
 }
 
+// Use 注册 GORM 插件（如分片、链路追踪），应在挂载路由前调用，插件作用于已配置连接池和日志的实例
+func (d *Database) Use(plugin gorm.Plugin) error {
+	if err := d.DB.Use(plugin); err != nil {
+		return fmt.Errorf("failed to register plugin %s: %v", plugin.Name(), err)
+	}
+	if d.logger != nil {
+		d.logger.Info("gorm plugin registered", zap.String("plugin", plugin.Name()))
+	}
+	return nil
+}
+
 // Transaction 事务封装
 func Transaction(db *gorm.DB, fc func(tx *gorm.DB) error) error {
 	return db.Transaction(func(tx *gorm.DB) error {
@@ -398,30 +710,175 @@ func Transaction(db *gorm.DB, fc func(tx *gorm.DB) error) error {
 	})
 }
 
-// CreateCounter4Table 为指定表创建触发计数器
-func CreateCounter4Table(db *Database, tableName string) {
-	sql := `
-        CREATE TABLE counters (
-            name VARCHAR(255) PRIMARY KEY,
-            counter INT NOT NULL DEFAULT 0
-        );
-    `
-	if err := db.DB.Exec(sql).Error; err == nil {
-		switch db.config.Type {
-		case MySQL, MariaDB, TiDB:
-			createMySQLTriggers(db.DB, tableName)
-		case PostgreSQL:
-			createPostgresTriggers(db.DB, tableName)
-		case SQLite:
-			createSQLiteTriggers(db.DB, tableName)
-		default:
-			log.Fatalf("unsupported database type: %s", db.config.Type)
+// CreateCounter4Table 为指定表创建触发计数器，groupColumns 为可选的分组计数列（应为低基数列）
+func CreateCounter4Table(db *Database, tableName string, groupColumns ...string) {
+	// 建表和触发器需在主库执行，包括其中的结构查询
+	if err := installCounters(db.Primary(), db.config.Type, tableName, groupColumns); err != nil {
+		log.Fatalf("%v", err)
+	}
+}
+
+// installCounters 创建计数器表和指定表的计数触发器，计数器表已存在时仅校验其主键
+// 触发器和计数行每次都重新创建（先删除旧的触发器，再按表中数据重新统计），已有计数器表的数据库同样会安装新表或新分组列的触发器
+func installCounters(db *gorm.DB, dbType DBType, tableName string, groupColumns []string) error {
+	// 登记分组计数列，供列表查询判断是否可以使用分组计数器；之前登记的分组列一并重建触发器，物理删除触发器需扣减所有分组
+	registerCounterGroups(tableName, groupColumns)
+	muCounterGroups.RLock()
+	groupColumns = append([]string(nil), counterGroups[tableName]...)
+	muCounterGroups.RUnlock()
+
+	// 先判断计数器表是否存在，事务中执行失败的语句会导致 PostgreSQL 中止整个事务
+	if db.Migrator().HasTable("counters") {
+		ensureCounterPrimaryKey(db)
+	} else {
+		sql := `
+            CREATE TABLE counters (
+                name VARCHAR(255) PRIMARY KEY,
+                counter INT NOT NULL DEFAULT 0
+            );
+        `
+		if err := db.Exec(sql).Error; err != nil {
+			return fmt.Errorf("failed to create counters table: %v", err)
 		}
 	}
+
+	switch dbType {
+	case MySQL, MariaDB, TiDB:
+		if err := createMySQLTriggers(db, tableName); err != nil {
+			return err
+		}
+		for _, column := range groupColumns {
+			if err := createMySQLGroupTriggers(db, tableName, column); err != nil {
+				return err
+			}
+		}
+		return createMySQLDeleteTrigger(db, tableName, groupColumns)
+	case PostgreSQL:
+		if err := createPostgresTriggers(db, tableName); err != nil {
+			return err
+		}
+		for _, column := range groupColumns {
+			if err := createPostgresGroupTriggers(db, tableName, column); err != nil {
+				return err
+			}
+		}
+		return createPostgresDeleteTrigger(db, tableName, groupColumns)
+	case SQLite:
+		if err := createSQLiteTriggers(db, tableName); err != nil {
+			return err
+		}
+		for _, column := range groupColumns {
+			if err := createSQLiteGroupTriggers(db, tableName, column); err != nil {
+				return err
+			}
+		}
+		return createSQLiteDeleteTrigger(db, tableName, groupColumns)
+	default:
+		return fmt.Errorf("unsupported database type: %s", dbType)
+	}
+}
+
+// Migrate 迁移模型并创建计数器，每个模型在单独的事务中完成，失败时回滚该模型并继续处理其余模型，返回所有模型的错误
+// 每个模型都会安装计数触发器并重新统计计数，与计数器表是否已存在无关；同时创建创建接口使用的幂等键表；MySQL 的 DDL 会隐式提交事务，失败时可能残留已创建的表或触发器
+func (d *Database) Migrate(models ...interface{}) error {
+	var errs []error
+	if err := d.Primary().AutoMigrate(&IdempotencyKey{}); err != nil {
+		errs = append(errs, fmt.Errorf("idempotency_keys: %v", err))
+	}
+	for _, model := range models {
+		_, modelPtr, _ := GetModelInfo(model)
+		tableName := TableNameOf(d.Primary(), model)
+		err := d.Primary().Transaction(func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(modelPtr); err != nil {
+				return fmt.Errorf("failed to migrate table: %v", err)
+			}
+			return installCounters(tx, d.config.Type, tableName, nil)
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", tableName, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ensureCounterPrimaryKey 确认计数器表的 name 列为主键，保证按名称读取计数为单点查询，否则补建唯一索引
+func ensureCounterPrimaryKey(db *gorm.DB) {
+	columnTypes, err := db.Migrator().ColumnTypes("counters")
+	if err != nil {
+		return
+	}
+	for _, columnType := range columnTypes {
+		if columnType.Name() != "name" {
+			continue
+		}
+		if primaryKey, ok := columnType.PrimaryKey(); ok && primaryKey {
+			return
+		}
+	}
+
+	GetLogger().Warn("counters.name is not primary key, creating unique index")
+	if err := db.Exec("CREATE UNIQUE INDEX u_counters_name ON counters (name)").Error; err != nil {
+		GetLogger().Error("failed to create counters index", zap.Error(err))
+	}
+}
+
+// GetCounter 按名称读取计数器，同一请求内重复读取同一计数器时使用缓存，计数器不存在时返回错误
+func GetCounter(c *gin.Context, db *gorm.DB, name string) (int64, error) {
+	cache, _ := c.Get("counters")
+	counters, ok := cache.(map[string]int64)
+	if !ok {
+		counters = make(map[string]int64)
+		c.Set("counters", counters)
+	}
+	if counter, exists := counters[name]; exists {
+		return counter, nil
+	}
+
+	var counter int64
+	result := db.Raw("SELECT counter FROM counters WHERE name = ?", name).Scan(&counter)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, fmt.Errorf("counter not found: %s", name)
+	}
+
+	counters[name] = counter
+	return counter, nil
+}
+
+var (
+	counterGroups   = make(map[string][]string)
+	muCounterGroups sync.RWMutex
+)
+
+// registerCounterGroups 登记表的分组计数列
+func registerCounterGroups(tableName string, groupColumns []string) {
+	muCounterGroups.Lock()
+	defer muCounterGroups.Unlock()
+
+	for _, column := range groupColumns {
+		if !ExistsIn(counterGroups[tableName], column) {
+			counterGroups[tableName] = append(counterGroups[tableName], column)
+		}
+	}
+}
+
+// HasGroupCounter 判断指定表的列是否维护了分组计数器
+func HasGroupCounter(tableName, column string) bool {
+	muCounterGroups.RLock()
+	defer muCounterGroups.RUnlock()
+
+	return ExistsIn(counterGroups[tableName], column)
+}
+
+// GroupCounterName 获取分组计数器在 counters 表中的名称，形如 tableName:column:value
+func GroupCounterName(tableName, column, value string) string {
+	return fmt.Sprintf("%s:%s:%s", tableName, column, value)
 }
 
 // createMySQLTriggers 为 MySQL 创建触发器
-func createMySQLTriggers(db *gorm.DB, tableName string) {
+func createMySQLTriggers(db *gorm.DB, tableName string) error {
 	triggerSQL := fmt.Sprintf(`
         -- 初始插入数据
         DELETE FROM counters WHERE name = '%s';
@@ -474,12 +931,13 @@ func createMySQLTriggers(db *gorm.DB, tableName string) {
 		tableName, tableName, tableName)
 
 	if err := db.Exec(triggerSQL).Error; err != nil {
-		log.Fatalf("failed to create mysql triggers for table %s: %v", tableName, err)
+		return fmt.Errorf("failed to create mysql triggers for table %s: %v", tableName, err)
 	}
+	return nil
 }
 
 // createPostgresTriggers 为 PostgreSQL 创建触发器
-func createPostgresTriggers(db *gorm.DB, tableName string) {
+func createPostgresTriggers(db *gorm.DB, tableName string) error {
 	triggerSQL := fmt.Sprintf(`
         -- 初始插入数据
         DELETE FROM counters WHERE name = '%s';
@@ -559,12 +1017,13 @@ func createPostgresTriggers(db *gorm.DB, tableName string) {
 		tableName, tableName, tableName)
 
 	if err := db.Exec(triggerSQL).Error; err != nil {
-		log.Fatalf("failed to create postgresql triggers for table %s: %v", tableName, err)
+		return fmt.Errorf("failed to create postgresql triggers for table %s: %v", tableName, err)
 	}
+	return nil
 }
 
 // createSQLiteTriggers 为 SQLite 创建触发器
-func createSQLiteTriggers(db *gorm.DB, tableName string) {
+func createSQLiteTriggers(db *gorm.DB, tableName string) error {
 	triggerSQL := fmt.Sprintf(`
         -- 初始插入数据
         DELETE FROM counters WHERE name = '%s';
@@ -596,6 +1055,283 @@ func createSQLiteTriggers(db *gorm.DB, tableName string) {
 		tableName, tableName, tableName, tableName, tableName, tableName, tableName, tableName)
 
 	if err := db.Exec(triggerSQL).Error; err != nil {
-		log.Fatalf("failed to create sqlite triggers for table %s: %v", tableName, err)
+		return fmt.Errorf("failed to create sqlite triggers for table %s: %v", tableName, err)
 	}
+	return nil
+}
+
+// createMySQLGroupTriggers 为 MySQL 创建分组计数触发器
+func createMySQLGroupTriggers(db *gorm.DB, tableName, column string) error {
+	prefix := GroupCounterName(tableName, column, "")
+	triggerSQL := fmt.Sprintf(`
+        -- 初始插入数据，按前缀精确匹配，LIKE 会把表名中的 _ 当作通配符
+        DELETE FROM counters WHERE substr(name, 1, %d) = '%s';
+        INSERT INTO counters (name, counter)
+            SELECT CONCAT('%s', COALESCE(%s, '')), COUNT(*) FROM %s WHERE deleted_at = 0 GROUP BY %s;
+
+        -- 删除旧的触发器
+        DROP TRIGGER IF EXISTS after_%s_%s_insert;
+        DROP TRIGGER IF EXISTS after_%s_%s_update;
+
+        -- 插入触发器
+        CREATE TRIGGER after_%s_%s_insert
+        AFTER INSERT ON %s
+        FOR EACH ROW
+        BEGIN
+            IF NEW.deleted_at = 0 THEN
+                INSERT IGNORE INTO counters (name, counter) VALUES (CONCAT('%s', COALESCE(NEW.%s, '')), 0);
+                UPDATE counters SET counter = counter + 1 WHERE name = CONCAT('%s', COALESCE(NEW.%s, ''));
+            END IF;
+        END;
+
+        -- 更新触发器（软删除、恢复以及分组列变更）
+        CREATE TRIGGER after_%s_%s_update
+        AFTER UPDATE ON %s
+        FOR EACH ROW
+        BEGIN
+            IF OLD.deleted_at = 0 THEN
+                UPDATE counters SET counter = counter - 1 WHERE name = CONCAT('%s', COALESCE(OLD.%s, ''));
+            END IF;
+            IF NEW.deleted_at = 0 THEN
+                INSERT IGNORE INTO counters (name, counter) VALUES (CONCAT('%s', COALESCE(NEW.%s, '')), 0);
+                UPDATE counters SET counter = counter + 1 WHERE name = CONCAT('%s', COALESCE(NEW.%s, ''));
+            END IF;
+        END;
+    `,
+		// 初始数据的参数
+		len(prefix), prefix, prefix, column, tableName, column,
+		// 删除旧触发器的参数
+		tableName, column, tableName, column,
+		// 插入触发器的参数
+		tableName, column, tableName,
+		prefix, column, prefix, column,
+		// 更新触发器的参数
+		tableName, column, tableName,
+		prefix, column,
+		prefix, column, prefix, column)
+
+	if err := db.Exec(triggerSQL).Error; err != nil {
+		return fmt.Errorf("failed to create mysql group triggers for table %s column %s: %v", tableName, column, err)
+	}
+	return nil
+}
+
+// createPostgresGroupTriggers 为 PostgreSQL 创建分组计数触发器
+func createPostgresGroupTriggers(db *gorm.DB, tableName, column string) error {
+	prefix := GroupCounterName(tableName, column, "")
+	triggerSQL := fmt.Sprintf(`
+        -- 初始插入数据，按前缀精确匹配，LIKE 会把表名中的 _ 当作通配符
+        DELETE FROM counters WHERE substr(name, 1, %d) = '%s';
+        INSERT INTO counters (name, counter)
+            SELECT '%s' || COALESCE(%s::text, ''), COUNT(*) FROM %s WHERE deleted_at = 0 GROUP BY %s;
+
+        -- 清理旧的触发器和函数
+        DROP TRIGGER IF EXISTS after_%s_%s_insert ON %s;
+        DROP TRIGGER IF EXISTS after_%s_%s_update ON %s;
+
+        DROP FUNCTION IF EXISTS fn_after_%s_%s_insert();
+        DROP FUNCTION IF EXISTS fn_after_%s_%s_update();
+
+        -- 创建插入触发器函数和触发器
+        CREATE OR REPLACE FUNCTION fn_after_%s_%s_insert()
+        RETURNS TRIGGER AS $$
+        BEGIN
+            IF NEW.deleted_at = 0 THEN
+                INSERT INTO counters (name, counter) VALUES ('%s' || COALESCE(NEW.%s::text, ''), 0)
+                    ON CONFLICT (name) DO NOTHING;
+                UPDATE counters SET counter = counter + 1 WHERE name = '%s' || COALESCE(NEW.%s::text, '');
+            END IF;
+            RETURN NEW;
+        END;
+        $$ LANGUAGE plpgsql;
+
+        CREATE TRIGGER after_%s_%s_insert
+            AFTER INSERT ON %s
+            FOR EACH ROW
+            EXECUTE FUNCTION fn_after_%s_%s_insert();
+
+        -- 创建更新触发器函数和触发器（软删除、恢复以及分组列变更）
+        CREATE OR REPLACE FUNCTION fn_after_%s_%s_update()
+        RETURNS TRIGGER AS $$
+        BEGIN
+            IF OLD.deleted_at = 0 THEN
+                UPDATE counters SET counter = counter - 1 WHERE name = '%s' || COALESCE(OLD.%s::text, '');
+            END IF;
+            IF NEW.deleted_at = 0 THEN
+                INSERT INTO counters (name, counter) VALUES ('%s' || COALESCE(NEW.%s::text, ''), 0)
+                    ON CONFLICT (name) DO NOTHING;
+                UPDATE counters SET counter = counter + 1 WHERE name = '%s' || COALESCE(NEW.%s::text, '');
+            END IF;
+            RETURN NEW;
+        END;
+        $$ LANGUAGE plpgsql;
+
+        CREATE TRIGGER after_%s_%s_update
+            AFTER UPDATE ON %s
+            FOR EACH ROW
+            EXECUTE FUNCTION fn_after_%s_%s_update();
+    `,
+		// 初始数据的参数
+		len(prefix), prefix, prefix, column, tableName, column,
+		// 删除旧触发器的参数
+		tableName, column, tableName, tableName, column, tableName,
+		// 删除旧函数的参数
+		tableName, column, tableName, column,
+		// 插入触发器的参数
+		tableName, column, prefix, column, prefix, column,
+		tableName, column, tableName, tableName, column,
+		// 更新触发器的参数
+		tableName, column, prefix, column,
+		prefix, column, prefix, column,
+		tableName, column, tableName, tableName, column)
+
+	if err := db.Exec(triggerSQL).Error; err != nil {
+		return fmt.Errorf("failed to create postgresql group triggers for table %s column %s: %v", tableName, column, err)
+	}
+	return nil
+}
+
+// createSQLiteGroupTriggers 为 SQLite 创建分组计数触发器
+func createSQLiteGroupTriggers(db *gorm.DB, tableName, column string) error {
+	prefix := GroupCounterName(tableName, column, "")
+	triggerSQL := fmt.Sprintf(`
+        -- 初始插入数据，按前缀精确匹配，LIKE 会把表名中的 _ 当作通配符
+        DELETE FROM counters WHERE substr(name, 1, %d) = '%s';
+        INSERT INTO counters (name, counter)
+            SELECT '%s' || COALESCE(CAST(%s AS TEXT), ''), COUNT(*) FROM %s WHERE deleted_at = 0 GROUP BY %s;
+
+        -- 清理旧的触发器
+        DROP TRIGGER IF EXISTS after_%s_%s_insert;
+        DROP TRIGGER IF EXISTS after_%s_%s_update;
+
+        -- 创建触发器维护分组计数
+        CREATE TRIGGER after_%s_%s_insert AFTER INSERT ON %s
+        WHEN NEW.deleted_at = 0
+        BEGIN
+            INSERT OR IGNORE INTO counters (name, counter) VALUES ('%s' || COALESCE(CAST(NEW.%s AS TEXT), ''), 0);
+            UPDATE counters SET counter = counter + 1 WHERE name = '%s' || COALESCE(CAST(NEW.%s AS TEXT), '');
+        END;
+
+        CREATE TRIGGER after_%s_%s_update AFTER UPDATE ON %s
+        BEGIN
+            UPDATE counters SET counter = counter - 1
+                WHERE OLD.deleted_at = 0 AND name = '%s' || COALESCE(CAST(OLD.%s AS TEXT), '');
+            INSERT OR IGNORE INTO counters (name, counter)
+                SELECT '%s' || COALESCE(CAST(NEW.%s AS TEXT), ''), 0 WHERE NEW.deleted_at = 0;
+            UPDATE counters SET counter = counter + 1
+                WHERE NEW.deleted_at = 0 AND name = '%s' || COALESCE(CAST(NEW.%s AS TEXT), '');
+        END;
+    `,
+		// 初始数据的参数
+		len(prefix), prefix, prefix, column, tableName, column,
+		// 清理旧触发器的参数
+		tableName, column, tableName, column,
+		// 插入触发器的参数
+		tableName, column, tableName,
+		prefix, column, prefix, column,
+		// 更新触发器的参数
+		tableName, column, tableName,
+		prefix, column,
+		prefix, column, prefix, column)
+
+	if err := db.Exec(triggerSQL).Error; err != nil {
+		return fmt.Errorf("failed to create sqlite group triggers for table %s column %s: %v", tableName, column, err)
+	}
+	return nil
+}
+
+// createMySQLDeleteTrigger 为 MySQL 创建物理删除触发器，删除未软删除的记录时扣减计数（含分组计数）
+func createMySQLDeleteTrigger(db *gorm.DB, tableName string, groupColumns []string) error {
+	statements := []string{fmt.Sprintf("UPDATE counters SET counter = counter - 1 WHERE name = '%s';", tableName)}
+	for _, column := range groupColumns {
+		statements = append(statements, fmt.Sprintf("UPDATE counters SET counter = counter - 1 WHERE name = CONCAT('%s', COALESCE(OLD.%s, ''));",
+			GroupCounterName(tableName, column, ""), column))
+	}
+
+	triggerSQL := fmt.Sprintf(`
+        -- 删除旧的触发器
+        DROP TRIGGER IF EXISTS after_%s_delete;
+
+        -- 物理删除触发器
+        CREATE TRIGGER after_%s_delete
+        AFTER DELETE ON %s
+        FOR EACH ROW
+        BEGIN
+            IF OLD.deleted_at = 0 THEN
+                %s
+            END IF;
+        END;
+    `, tableName, tableName, tableName, strings.Join(statements, "\n                "))
+
+	if err := db.Exec(triggerSQL).Error; err != nil {
+		return fmt.Errorf("failed to create mysql delete trigger for table %s: %v", tableName, err)
+	}
+	return nil
+}
+
+// createPostgresDeleteTrigger 为 PostgreSQL 创建物理删除触发器，删除未软删除的记录时扣减计数（含分组计数）
+func createPostgresDeleteTrigger(db *gorm.DB, tableName string, groupColumns []string) error {
+	statements := []string{fmt.Sprintf("UPDATE counters SET counter = counter - 1 WHERE name = '%s';", tableName)}
+	for _, column := range groupColumns {
+		statements = append(statements, fmt.Sprintf("UPDATE counters SET counter = counter - 1 WHERE name = '%s' || COALESCE(OLD.%s::text, '');",
+			GroupCounterName(tableName, column, ""), column))
+	}
+
+	triggerSQL := fmt.Sprintf(`
+        -- 清理旧的触发器和函数
+        DROP TRIGGER IF EXISTS after_%s_delete ON %s;
+        DROP FUNCTION IF EXISTS fn_after_%s_delete();
+
+        -- 创建物理删除触发器函数和触发器
+        CREATE OR REPLACE FUNCTION fn_after_%s_delete()
+        RETURNS TRIGGER AS $$
+        BEGIN
+            IF OLD.deleted_at = 0 THEN
+                %s
+            END IF;
+            RETURN OLD;
+        END;
+        $$ LANGUAGE plpgsql;
+
+        CREATE TRIGGER after_%s_delete
+            AFTER DELETE ON %s
+            FOR EACH ROW
+            EXECUTE FUNCTION fn_after_%s_delete();
+    `,
+		// 删除旧触发器和函数的参数
+		tableName, tableName, tableName,
+		// 物理删除触发器的参数
+		tableName, strings.Join(statements, "\n                "),
+		tableName, tableName, tableName)
+
+	if err := db.Exec(triggerSQL).Error; err != nil {
+		return fmt.Errorf("failed to create postgresql delete trigger for table %s: %v", tableName, err)
+	}
+	return nil
+}
+
+// createSQLiteDeleteTrigger 为 SQLite 创建物理删除触发器，删除未软删除的记录时扣减计数（含分组计数）
+func createSQLiteDeleteTrigger(db *gorm.DB, tableName string, groupColumns []string) error {
+	statements := []string{fmt.Sprintf("UPDATE counters SET counter = counter - 1 WHERE name = '%s';", tableName)}
+	for _, column := range groupColumns {
+		statements = append(statements, fmt.Sprintf("UPDATE counters SET counter = counter - 1 WHERE name = '%s' || COALESCE(CAST(OLD.%s AS TEXT), '');",
+			GroupCounterName(tableName, column, ""), column))
+	}
+
+	triggerSQL := fmt.Sprintf(`
+        -- 清理旧的触发器
+        DROP TRIGGER IF EXISTS after_%s_delete;
+
+        -- 物理删除未软删除的记录时扣减计数
+        CREATE TRIGGER after_%s_delete AFTER DELETE ON %s
+        WHEN OLD.deleted_at = 0
+        BEGIN
+            %s
+        END;
+    `, tableName, tableName, tableName, strings.Join(statements, "\n            "))
+
+	if err := db.Exec(triggerSQL).Error; err != nil {
+		return fmt.Errorf("failed to create sqlite delete trigger for table %s: %v", tableName, err)
+	}
+	return nil
 }
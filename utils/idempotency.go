@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// IdempotencyKeyHeader 幂等键请求头
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// ErrIdempotencyKeyReused 同一幂等键对应了不同的请求内容
+var ErrIdempotencyKeyReused = errors.New("idempotency key reused with a different request")
+
+// IdempotencyKey 幂等键记录，保存创建请求的幂等键和创建的资源主键，由 Database.Migrate 创建
+type IdempotencyKey struct {
+	Key         string `gorm:"column:idempotency_key;primarykey;type:varchar(255)"` // 资源名和幂等键，key 为 MySQL 保留字，列名使用 idempotency_key
+	Fingerprint string `gorm:"type:varchar(64)"`                                    // 请求内容摘要，用于发现幂等键被不同的请求复用
+	ResourceIDs string `gorm:"type:text"`                                           // 创建的资源主键，JSON 数组
+	CreatedAt   int64  `gorm:"autoCreateTime:milli;index:i_idempotency_keys_created_at"`
+}
+
+// TableName 幂等键表名
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
+
+// RequestFingerprint 计算请求内容的摘要
+func RequestFingerprint(v interface{}) string {
+	data, _ := json.Marshal(v)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// LookupIdempotencyKey 查找未过期的幂等键，返回之前创建的资源主键，不存在或已过期时返回 nil
+// 过期的记录会被删除，之后同一幂等键可以重新使用；fingerprint 与之前的请求不一致时返回 ErrIdempotencyKeyReused
+func LookupIdempotencyKey(db *gorm.DB, resource, key, fingerprint string, ttl time.Duration) ([]interface{}, error) {
+	var record IdempotencyKey
+	result := db.Where("idempotency_key = ?", resource+":"+key).Limit(1).Find(&record)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	if time.Since(time.UnixMilli(record.CreatedAt)) > ttl {
+		if err := db.Delete(&record).Error; err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if record.Fingerprint != fingerprint {
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	var ids []interface{}
+	if err := json.Unmarshal([]byte(record.ResourceIDs), &ids); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// SaveIdempotencyKey 保存幂等键和创建的资源主键，应与创建操作在同一事务中执行；并发请求使用同一幂等键时主键冲突，后提交的请求失败
+func SaveIdempotencyKey(db *gorm.DB, resource, key, fingerprint string, ids []interface{}) error {
+	data, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	err = db.Create(&IdempotencyKey{
+		Key:         resource + ":" + key,
+		Fingerprint: fingerprint,
+		ResourceIDs: string(data),
+	}).Error
+
+	// 将驱动的唯一约束错误转换为 gorm.ErrDuplicatedKey
+	if translator, ok := db.Dialector.(gorm.ErrorTranslator); ok && err != nil {
+		err = translator.Translate(err)
+	}
+	return err
+}
@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// 索引检查状态
+const (
+	IndexOK         = "ok"         // 已存在
+	IndexMissing    = "missing"    // 模型声明或建议的索引在数据库中不存在
+	IndexUnexpected = "unexpected" // 数据库中存在但模型未声明
+)
+
+// 索引来源
+const (
+	IndexSourceModel    = "model"    // 模型 gorm 标签声明的索引
+	IndexSourceCtags    = "ctags"    // ctags 查询或排序字段建议的索引
+	IndexSourceDatabase = "database" // 仅存在于数据库中的索引
+)
+
+// IndexStatus 单个索引的检查结果
+type IndexStatus struct {
+	Table   string   `json:"table"`
+	Name    string   `json:"name,omitempty"` // 索引名，ctags 建议的索引没有名称
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique"`
+	Source  string   `json:"source"`
+	Status  string   `json:"status"`
+}
+
+// IndexReport 对比模型声明的索引与数据库中实际存在的索引，用于发现未完整执行的迁移
+// ctags 标记为查询(q)或排序(o)的字段没有以其为首列的索引时同样报告为缺失
+func IndexReport(db *gorm.DB, models ...interface{}) ([]IndexStatus, error) {
+	var report []IndexStatus
+
+	for _, model := range models {
+		_, modelPtr, _ := GetModelInfo(model)
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(modelPtr); err != nil {
+			return nil, fmt.Errorf("failed to parse model %T: %v", model, err)
+		}
+		table := stmt.Schema.Table
+
+		// 表不存在时所有索引均视为缺失
+		existing := make(map[string]gorm.Index)
+		if db.Migrator().HasTable(modelPtr) {
+			indexes, err := db.Migrator().GetIndexes(modelPtr)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get indexes of %s: %v", table, err)
+			}
+			for _, index := range indexes {
+				existing[index.Name()] = index
+			}
+		}
+
+		// 模型声明的索引
+		declared := make(map[string]bool)
+		leading := make(map[string]bool)
+		for name, index := range stmt.Schema.ParseIndexes() {
+			columns := make([]string, 0, len(index.Fields))
+			for _, field := range index.Fields {
+				columns = append(columns, field.DBName)
+			}
+			status := IndexMissing
+			if _, ok := existing[name]; ok {
+				status = IndexOK
+			}
+			declared[name] = true
+			if len(columns) > 0 {
+				leading[columns[0]] = true
+			}
+			report = append(report, IndexStatus{
+				Table:   table,
+				Name:    name,
+				Columns: columns,
+				Unique:  index.Class == "UNIQUE",
+				Source:  IndexSourceModel,
+				Status:  status,
+			})
+		}
+
+		// ctags 查询和排序字段建议建立以其为首列的索引，模型已声明时不重复报告
+		for _, field := range stmt.Schema.Fields {
+			tag := field.Tag.Get("ctags")
+			if tag == "" || field.PrimaryKey || field.DBName == "" || leading[field.DBName] {
+				continue
+			}
+			tags := strings.Split(tag, ",")[1:]
+			if !(ExistsIn(tags, "q") || ExistsIn(tags, "o")) || ExistsIn(tags, "encrypt") {
+				continue
+			}
+
+			status := IndexMissing
+			for _, index := range existing {
+				if columns := index.Columns(); len(columns) > 0 && columns[0] == field.DBName {
+					status = IndexOK
+					break
+				}
+			}
+			report = append(report, IndexStatus{
+				Table:   table,
+				Columns: []string{field.DBName},
+				Source:  IndexSourceCtags,
+				Status:  status,
+			})
+		}
+
+		// 数据库中存在但模型未声明的索引，主键索引除外
+		for name, index := range existing {
+			if declared[name] {
+				continue
+			}
+			if primaryKey, ok := index.PrimaryKey(); ok && primaryKey {
+				continue
+			}
+			unique, _ := index.Unique()
+			report = append(report, IndexStatus{
+				Table:   table,
+				Name:    name,
+				Columns: index.Columns(),
+				Unique:  unique,
+				Source:  IndexSourceDatabase,
+				Status:  IndexUnexpected,
+			})
+		}
+	}
+
+	sort.SliceStable(report, func(i, j int) bool {
+		if report[i].Table != report[j].Table {
+			return report[i].Table < report[j].Table
+		}
+		if report[i].Name != report[j].Name {
+			return report[i].Name < report[j].Name
+		}
+		return strings.Join(report[i].Columns, ",") < strings.Join(report[j].Columns, ",")
+	})
+	return report, nil
+}
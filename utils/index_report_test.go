@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+// indexItem 索引检查测试使用的模型
+type indexItem struct {
+	ID     uint   `gorm:"primarykey"`
+	Email  string `gorm:"index:i_index_item_email"`
+	Code   string `gorm:"uniqueIndex:u_index_item_code"`
+	Status string `ctags:"status,q"`
+	Name   string `ctags:"name,o" gorm:"index:i_index_item_name"`
+	Note   string
+}
+
+func TestIndexReport(t *testing.T) {
+	db := openTestDataBase(t)
+	if err := db.AutoMigrate(&indexItem{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	// 模拟未完整执行的迁移和手工添加的索引
+	db.Exec("DROP INDEX i_index_item_email")
+	db.Exec("CREATE INDEX x_index_item_note ON index_items (note)")
+
+	report, err := IndexReport(db.DB, indexItem{})
+	if err != nil {
+		t.Fatalf("failed to build index report: %v", err)
+	}
+	want := []IndexStatus{
+		{Table: "index_items", Columns: []string{"status"}, Source: IndexSourceCtags, Status: IndexMissing},
+		{Table: "index_items", Name: "i_index_item_email", Columns: []string{"email"}, Source: IndexSourceModel, Status: IndexMissing},
+		{Table: "index_items", Name: "i_index_item_name", Columns: []string{"name"}, Source: IndexSourceModel, Status: IndexOK},
+		{Table: "index_items", Name: "u_index_item_code", Columns: []string{"code"}, Unique: true, Source: IndexSourceModel, Status: IndexOK},
+		{Table: "index_items", Name: "x_index_item_note", Columns: []string{"note"}, Source: IndexSourceDatabase, Status: IndexUnexpected},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Fatalf("index report = %+v\nwant %+v", report, want)
+	}
+
+	// 补建索引后不再报告缺失
+	db.Exec("CREATE INDEX i_index_item_email ON index_items (email)")
+	db.Exec("CREATE INDEX i_index_item_status ON index_items (status, note)")
+	report, err = IndexReport(db.DB, indexItem{})
+	if err != nil {
+		t.Fatalf("failed to build index report: %v", err)
+	}
+	for _, status := range report {
+		if status.Status == IndexMissing {
+			t.Fatalf("index still reported missing: %+v", status)
+		}
+	}
+}
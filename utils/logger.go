@@ -7,9 +7,9 @@ import (
 	"runtime"
 	"strings"
 	"sync"
-	"syscall"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -18,74 +18,114 @@ import (
 
 // LogConfig 日志配置结构体
 type LogConfig struct {
-	Level         string `mapstructure:"level"`         // 日志级别
-	Directory     string `mapstructure:"directory"`     // 日志目录
-	SeparateLevel bool   `mapstructure:"separateLevel"` // 是否按级别分割日志文件
-	MaxSize       int    `mapstructure:"maxSize"`       // 单个日志文件最大大小，单位MB
-	MaxBackups    int    `mapstructure:"maxBackups"`    // 最大保留的旧文件数量
-	MaxAge        int    `mapstructure:"maxAge"`        // 旧文件保留天数
-	Compress      bool   `mapstructure:"compress"`      // 是否压缩旧文件
-	Console       bool   `mapstructure:"console"`       // 是否输出到控制台
-	TraceID       string `mapstructure:"traceID"`       // 链路追踪ID字段名
+	Level            string          `mapstructure:"level"`            // 日志级别
+	Directory        string          `mapstructure:"directory"`        // 日志目录
+	SeparateLevel    bool            `mapstructure:"separateLevel"`    // 是否按级别分割日志文件
+	MaxSize          int             `mapstructure:"maxSize"`          // 单个日志文件最大大小，单位MB
+	MaxBackups       int             `mapstructure:"maxBackups"`       // 最大保留的旧文件数量
+	MaxAge           int             `mapstructure:"maxAge"`           // 旧文件保留天数
+	Compress         bool            `mapstructure:"compress"`         // 是否压缩旧文件
+	Console          bool            `mapstructure:"console"`          // 是否输出到控制台
+	TraceID          string          `mapstructure:"traceID"`          // 链路追踪ID字段名
+	RotationInterval string          `mapstructure:"rotationInterval"` // 日志文件切分周期：daily-按天，hourly-按小时
+	ConsoleEncoder   string          `mapstructure:"consoleEncoder"`   // 控制台输出格式：console-可读文本（级别着色），json-JSON
+	FileEncoder      string          `mapstructure:"fileEncoder"`      // 文件输出格式：json-JSON，console-可读文本
+	Sampling         *SamplingConfig `mapstructure:"sampling"`         // 日志采样配置，为空时不采样
+}
+
+// SamplingConfig 日志采样配置，每秒内相同级别和内容的日志先记录 Initial 条，之后每 Thereafter 条记录一条
+type SamplingConfig struct {
+	Initial    int `mapstructure:"initial"`    // 每秒完整记录的条数
+	Thereafter int `mapstructure:"thereafter"` // 超出后的采样间隔，为 0 时丢弃超出的日志
 }
 
 // Logger 日志结构体
 type Logger struct {
-	config *LogConfig
-	logger *zap.Logger
+	config  *LogConfig
+	logger  *zap.Logger
+	encoder zapcore.EncoderConfig // 编码器配置
+	writers *sync.Map             // 日志文件写入器，派生的日志实例共享
+	levels  sync.Map              // 按级别派生的日志实例
 	sync.Once
 }
 
 // 默认配置
 var defaultLogConfig = LogConfig{
-	Level:         "info",
-	Directory:     "logs",
-	SeparateLevel: false,
-	MaxSize:       100,
-	MaxBackups:    30,
-	MaxAge:        7,
-	Compress:      true,
-	Console:       true,
-	TraceID:       "trace_id",
+	Level:            "info",
+	Directory:        "logs",
+	SeparateLevel:    false,
+	MaxSize:          100,
+	MaxBackups:       30,
+	MaxAge:           7,
+	Compress:         true,
+	Console:          true,
+	TraceID:          "trace_id",
+	RotationInterval: "daily",
+	ConsoleEncoder:   "console",
+	FileEncoder:      "json",
 }
 
+// logCallerSkip Debug/Info 等包装方法在调用栈中占用的层数，zap 的 caller 字段和 getBaseFields 共用，
+// 确保记录的文件和行号均指向实际调用处
+const logCallerSkip = 1
+
 var (
-	instanceLog *Logger
-	onceLog     sync.Once
+	instanceLogs = make(map[string]*Logger)
+	muLog        sync.RWMutex
 )
 
 // GetLogger 获取日志实例，支持多种初始化方式
+// 按参数区分实例，不同配置文件或配置段返回相互独立的实例，如访问日志和 SQL 日志分别写入不同目录；无参数时返回默认实例
 func GetLogger(args ...string) *Logger {
-	onceLog.Do(func() {
-		var config *LogConfig
-		var err error
-
-		switch len(args) {
-		case 0:
-			// 使用默认配置
-			config = &defaultLogConfig
-		case 1:
-			// 使用配置文件，默认段
-			config, err = loadLogConfig(args[0], "logger")
-		case 2:
-			// 使用配置文件，指定段
-			config, err = loadLogConfig(args[0], args[1])
-		default:
-			panic("invalid parameters")
-		}
+	key := strings.Join(args, ":")
 
-		if err != nil {
-			panic(fmt.Sprintf("failed to initialize log: %v", err))
-		}
+	muLog.RLock()
+	if l, exists := instanceLogs[key]; exists {
+		muLog.RUnlock()
+		return l
+	}
+	muLog.RUnlock()
 
-		instanceLog = &Logger{
-			config: config,
-		}
-		if err := instanceLog.initLogger(); err != nil {
-			panic(fmt.Sprintf("failed to initialize log: %v", err))
-		}
-	})
-	return instanceLog
+	muLog.Lock()
+	defer muLog.Unlock()
+
+	// 双重检查
+	if l, exists := instanceLogs[key]; exists {
+		return l
+	}
+
+	var config *LogConfig
+	var err error
+
+	switch len(args) {
+	case 0:
+		// 使用默认配置
+		defaultConfig := defaultLogConfig
+		config = &defaultConfig
+	case 1:
+		// 使用配置文件，默认段
+		config, err = loadLogConfig(args[0], "logger")
+	case 2:
+		// 使用配置文件，指定段
+		config, err = loadLogConfig(args[0], args[1])
+	default:
+		panic("invalid parameters")
+	}
+
+	if err != nil {
+		panic(fmt.Sprintf("failed to initialize log: %v", err))
+	}
+
+	l := &Logger{
+		config: config,
+	}
+	if err := l.initLogger(); err != nil {
+		panic(fmt.Sprintf("failed to initialize log: %v", err))
+	}
+
+	instanceLogs[key] = l
+
+	return l
 }
 
 // loadLogConfig 加载配置文件
@@ -153,6 +193,15 @@ func (l *Logger) initLogger() error {
 		EncodeCaller:   zapcore.ShortCallerEncoder,
 	}
 
+	l.encoder = encoderConfig
+	l.writers = &sync.Map{}
+	l.logger = l.buildLogger(getLogLevel(l.config.Level))
+
+	return nil
+}
+
+// buildLogger 按指定级别创建 zap 日志对象，按级别分割的文件不受影响
+func (l *Logger) buildLogger(minLevel zapcore.Level) *zap.Logger {
 	// 创建核心
 	var cores []zapcore.Core
 
@@ -166,56 +215,130 @@ func (l *Logger) initLogger() error {
 			zapcore.FatalLevel,
 		}
 		for _, level := range levels {
-			core := l.createLevelCore(level, encoderConfig)
+			core := l.createLevelCore(level)
 			cores = append(cores, core)
 		}
 	} else {
-		core := l.createLevelCore(getLogLevel(l.config.Level), encoderConfig)
+		core := l.createLevelCore(minLevel)
 		cores = append(cores, core)
 	}
 
 	// 控制台输出
 	if l.config.Console {
-		consoleEncoder := zapcore.NewConsoleEncoder(encoderConfig)
 		consoleCore := zapcore.NewCore(
-			consoleEncoder,
+			l.newEncoder(l.config.ConsoleEncoder, true),
 			zapcore.AddSync(os.Stdout),
-			getLogLevel(l.config.Level),
+			minLevel,
 		)
 		cores = append(cores, consoleCore)
 	}
 
+	core := zapcore.NewTee(cores...)
+
+	// 日志采样，避免大量重复日志写满磁盘
+	if sampling := l.config.Sampling; sampling != nil && sampling.Initial > 0 {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, sampling.Initial, sampling.Thereafter)
+	}
+
 	// 创建logger
-	l.logger = zap.New(
-		zapcore.NewTee(cores...),
+	return zap.New(
+		core,
 		zap.AddCaller(),
-		zap.AddCallerSkip(1),
+		zap.AddCallerSkip(logCallerSkip),
 	)
+}
 
-	return nil
+// WithLevel 获取指定级别的日志实例，与原实例共享输出文件，level 为空或无效时返回原实例
+func (l *Logger) WithLevel(level string) *Logger {
+	if level == "" || strings.EqualFold(level, l.config.Level) {
+		return l
+	}
+	minLevel, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return l
+	}
+
+	if derived, ok := l.levels.Load(minLevel); ok {
+		return derived.(*Logger)
+	}
+	derived, _ := l.levels.LoadOrStore(minLevel, &Logger{
+		config:  l.config,
+		logger:  l.buildLogger(minLevel),
+		encoder: l.encoder,
+		writers: l.writers,
+	})
+	return derived.(*Logger)
+}
+
+// GetLoggerByCtx 获取当前请求的日志实例，资源配置了日志级别时返回对应级别的实例
+func GetLoggerByCtx(c *gin.Context) *Logger {
+	return GetLogger().WithLevel(c.GetString("log_level"))
+}
+
+// newEncoder 按输出格式创建编码器，colorize 为 true 时可读文本格式对级别着色
+func (l *Logger) newEncoder(format string, colorize bool) zapcore.Encoder {
+	encoderConfig := l.encoder
+	if strings.ToLower(format) == "console" {
+		if colorize {
+			encoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
+		return zapcore.NewConsoleEncoder(encoderConfig)
+	}
+	return zapcore.NewJSONEncoder(encoderConfig)
 }
 
 // createLevelCore 创建特定级别的日志核心
-func (l *Logger) createLevelCore(level zapcore.Level, encoderConfig zapcore.EncoderConfig) zapcore.Core {
-	filename := l.getLogFileName(level)
-	writer := &lumberjack.Logger{
-		Filename:   filename,
-		MaxSize:    l.config.MaxSize,
-		MaxBackups: l.config.MaxBackups,
-		MaxAge:     l.config.MaxAge,
-		Compress:   l.config.Compress,
+func (l *Logger) createLevelCore(level zapcore.Level) zapcore.Core {
+	// 不按级别分割时所有级别共用一个写入器
+	key := ""
+	if l.config.SeparateLevel {
+		key = level.String()
 	}
+	writer, _ := l.writers.LoadOrStore(key, &rotatingWriter{logger: l, level: level})
 
 	return zapcore.NewCore(
-		zapcore.NewJSONEncoder(encoderConfig),
-		zapcore.AddSync(writer),
+		l.newEncoder(l.config.FileEncoder, false),
+		zapcore.AddSync(writer.(*rotatingWriter)),
 		level,
 	)
 }
 
-// getLogFileName 获取日志文件名
+// rotatingWriter 按切分周期写入日志文件，跨越周期边界时切换到新文件，文件大小由 lumberjack 控制
+type rotatingWriter struct {
+	logger   *Logger
+	level    zapcore.Level
+	filename string
+	writer   *lumberjack.Logger
+	mu       sync.Mutex
+}
+
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if filename := w.logger.getLogFileName(w.level); filename != w.filename {
+		if w.writer != nil {
+			w.writer.Close()
+		}
+		w.filename = filename
+		w.writer = &lumberjack.Logger{
+			Filename:   filename,
+			MaxSize:    w.logger.config.MaxSize,
+			MaxBackups: w.logger.config.MaxBackups,
+			MaxAge:     w.logger.config.MaxAge,
+			Compress:   w.logger.config.Compress,
+		}
+	}
+	return w.writer.Write(p)
+}
+
+// getLogFileName 获取当前切分周期的日志文件名
 func (l *Logger) getLogFileName(level zapcore.Level) string {
-	date := time.Now().Format("2006-01-02")
+	layout := "2006-01-02"
+	if strings.ToLower(l.config.RotationInterval) == "hourly" {
+		layout = "2006-01-02-15"
+	}
+	date := time.Now().Format(layout)
 	if l.config.SeparateLevel {
 		return filepath.Join(l.config.Directory, fmt.Sprintf("%s-%s.log", date, level.String()))
 	}
@@ -271,19 +394,35 @@ func (l *Logger) Fatal(msg string, fields ...zap.Field) {
 	l.logger.Fatal(msg, fields...)
 }
 
+// TraceIDKey 获取链路追踪ID字段名，同时作为 gin 上下文中保存链路追踪ID的键
+func (l *Logger) TraceIDKey() string {
+	return l.config.TraceID
+}
+
+// Ctx 获取携带当前请求链路追踪ID、请求方法和路径的日志对象
+func (l *Logger) Ctx(c *gin.Context) *zap.Logger {
+	// 返回的对象由调用方直接使用，需抵消为包装方法设置的调用栈跳过层数
+	return l.logger.WithOptions(zap.AddCallerSkip(-logCallerSkip)).With(
+		zap.String(l.config.TraceID, c.GetString(l.config.TraceID)),
+		zap.String("method", c.Request.Method),
+		zap.String("path", c.Request.URL.Path),
+	)
+}
+
 // WithTraceID 添加链路追踪ID
 func (l *Logger) WithTraceID(traceID string) *zap.Logger {
-	return l.logger.With(zap.String(l.config.TraceID, traceID))
+	return l.logger.WithOptions(zap.AddCallerSkip(-logCallerSkip)).With(zap.String(l.config.TraceID, traceID))
 }
 
-// getBaseFields 获取基本字段信息
+// getBaseFields 获取基本字段信息，需由包装方法直接调用
 func getBaseFields() []zap.Field {
-	pc, file, line, _ := runtime.Caller(2)
+	// 跳过 getBaseFields 自身和包装方法
+	pc, file, line, _ := runtime.Caller(1 + logCallerSkip)
 	function := runtime.FuncForPC(pc).Name()
 
 	return []zap.Field{
-		zap.Int("pid", os.Getpid()),                 // 进程ID
-		zap.Uint64("tid", uint64(syscall.Gettid())), // 线程ID
+		zap.Int("pid", os.Getpid()),      // 进程ID
+		zap.Uint64("tid", getThreadID()), // 线程ID
 		zap.String("file", file),
 		zap.Int("line", line),
 		zap.String("func", function),
@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestLogger 创建只写入临时目录的日志实例
+func newTestLogger(t *testing.T, rotationInterval string) *Logger {
+	t.Helper()
+	config := defaultLogConfig
+	config.Directory = t.TempDir()
+	config.Console = false
+	config.Compress = false
+	config.RotationInterval = rotationInterval
+	l := &Logger{config: &config}
+	if err := l.initLogger(); err != nil {
+		t.Fatalf("failed to initialize logger: %v", err)
+	}
+	return l
+}
+
+// readLogEntries 读取日志文件中的 JSON 日志
+func readLogEntries(t *testing.T, filename string) []map[string]interface{} {
+	t.Helper()
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestLogFileNameRotationInterval(t *testing.T) {
+	for interval, layout := range map[string]string{"daily": "2006-01-02", "hourly": "2006-01-02-15"} {
+		l := newTestLogger(t, interval)
+		before := time.Now().Format(layout) + ".log"
+		filename := filepath.Base(l.getLogFileName(zapcore.InfoLevel))
+		after := time.Now().Format(layout) + ".log"
+		if filename != before && filename != after {
+			t.Errorf("%s log file = %s, want %s", interval, filename, before)
+		}
+	}
+}
+
+func TestRotatingWriterSwitchesFile(t *testing.T) {
+	l := newTestLogger(t, "hourly")
+
+	// 模拟上一周期打开的文件，跨越周期边界后写入应切换到当前周期的文件
+	stale := filepath.Join(l.config.Directory, "stale.log")
+	w := &rotatingWriter{logger: l, level: zapcore.InfoLevel, filename: stale, writer: &lumberjack.Logger{Filename: stale}}
+	if _, err := w.Write([]byte("current\n")); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	w.writer.Close()
+
+	if w.filename == stale {
+		t.Fatalf("writer did not switch from stale file")
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("stale file should not be written")
+	}
+	data, err := os.ReadFile(w.filename)
+	if err != nil || string(data) != "current\n" {
+		t.Fatalf("current file = %q, %v", data, err)
+	}
+}
+
+func TestLoggerReportsCallSite(t *testing.T) {
+	l := newTestLogger(t, "daily")
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/", nil)
+
+	_, file, line, _ := runtime.Caller(0)
+	l.Info("wrapper")
+	l.Ctx(c).Info("context")
+	l.WithTraceID("trace-1").Info("trace")
+	l.logger.Sync()
+
+	entries := readLogEntries(t, l.getLogFileName(zapcore.InfoLevel))
+	if len(entries) != 3 {
+		t.Fatalf("log entries = %d, want 3", len(entries))
+	}
+	if entries[2][l.config.TraceID] != "trace-1" {
+		t.Errorf("trace entry = %v", entries[2])
+	}
+	// 包装方法的 file、line 字段与 zap 的 caller 字段均指向调用处
+	wrapper := entries[0]
+	if wrapper["file"] != file || wrapper["line"] != float64(line+1) {
+		t.Errorf("wrapper file/line = %v:%v, want %s:%d", wrapper["file"], wrapper["line"], file, line+1)
+	}
+	for i, entry := range entries {
+		want := filepath.Base(file) + ":"
+		if caller, _ := entry["caller"].(string); !strings.Contains(caller, want) || !strings.HasSuffix(caller, ":"+strconv.Itoa(line+1+i)) {
+			t.Errorf("entry %d caller = %v, want %s%d", i, entry["caller"], want, line+1+i)
+		}
+	}
+	if !strings.HasSuffix(entries[0]["func"].(string), "TestLoggerReportsCallSite") {
+		t.Errorf("func = %v", entries[0]["func"])
+	}
+}
+
+func TestLoggerLevelByContext(t *testing.T) {
+	l := newTestLogger(t, "daily")
+	muLog.Lock()
+	previous, exists := instanceLogs[""]
+	instanceLogs[""] = l
+	muLog.Unlock()
+	defer func() {
+		muLog.Lock()
+		defer muLog.Unlock()
+		if exists {
+			instanceLogs[""] = previous
+		} else {
+			delete(instanceLogs, "")
+		}
+	}()
+
+	// 配置了 debug 级别的资源输出 debug 日志，其他资源沿用全局的 info 级别
+	for _, level := range []string{"", "debug", "invalid"} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/", nil)
+		if level != "" {
+			c.Set("log_level", level)
+		}
+		logger := GetLoggerByCtx(c)
+		logger.Debug("debug", zap.String("resource", level))
+		logger.Info("info", zap.String("resource", level))
+	}
+	if l.WithLevel("debug") != l.WithLevel("DEBUG") {
+		t.Errorf("derived loggers of the same level should be shared")
+	}
+
+	counts := map[string]int{}
+	for _, entry := range readLogEntries(t, l.getLogFileName(zapcore.InfoLevel)) {
+		counts[entry["resource"].(string)]++
+	}
+	if counts["debug"] != 2 || counts[""] != 1 || counts["invalid"] != 1 {
+		t.Fatalf("entries per resource = %v, want debug resource to log more", counts)
+	}
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetTrustedProxies 设置可信代理，仅来自可信代理的请求才会从 headers 中解析客户端 IP
+// proxies 为空时不信任任何代理，直接使用连接的远端地址；headers 为空时使用 gin 默认的 X-Forwarded-For 和 X-Real-IP
+func SetTrustedProxies(r *gin.Engine, proxies []string, headers ...string) error {
+	if err := r.SetTrustedProxies(proxies); err != nil {
+		return fmt.Errorf("failed to set trusted proxies: %v", err)
+	}
+	if len(headers) > 0 {
+		r.RemoteIPHeaders = headers
+	}
+	return nil
+}
+
+// ClientIP 获取请求的客户端 IP，按 SetTrustedProxies 的配置解析代理请求头，
+// 限流、审计等依赖客户端 IP 的功能应统一使用该方法
+func ClientIP(c *gin.Context) string {
+	if ip := c.ClientIP(); ip != "" {
+		return ip
+	}
+
+	// 远端地址无法解析时原样返回
+	host, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+	if err != nil {
+		return c.Request.RemoteAddr
+	}
+	return host
+}
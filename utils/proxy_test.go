@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		proxies    []string
+		headers    []string
+		remoteAddr string
+		header     string
+		value      string
+		want       string
+	}{
+		// 来自可信代理的请求从 X-Forwarded-For 中解析客户端 IP，跳过链路中的可信代理
+		{[]string{"10.0.0.0/8"}, nil, "10.0.0.1:1234", "X-Forwarded-For", "203.0.113.7, 10.0.0.2", "203.0.113.7"},
+		// 不可信来源伪造的请求头被忽略
+		{[]string{"10.0.0.0/8"}, nil, "198.51.100.9:1234", "X-Forwarded-For", "203.0.113.7", "198.51.100.9"},
+		// 不信任任何代理时使用远端地址
+		{nil, nil, "10.0.0.1:1234", "X-Forwarded-For", "203.0.113.7", "10.0.0.1"},
+		// 自定义客户端 IP 请求头
+		{[]string{"10.0.0.1"}, []string{"CF-Connecting-IP"}, "10.0.0.1:1234", "CF-Connecting-IP", "203.0.113.8", "203.0.113.8"},
+		{[]string{"10.0.0.1"}, []string{"CF-Connecting-IP"}, "10.0.0.1:1234", "X-Forwarded-For", "203.0.113.7", "10.0.0.1"},
+		// 远端地址无法解析时原样返回
+		{nil, nil, "unix-socket", "", "", "unix-socket"},
+	}
+	for _, tt := range tests {
+		r := gin.New()
+		if err := SetTrustedProxies(r, tt.proxies, tt.headers...); err != nil {
+			t.Fatalf("failed to set trusted proxies: %v", err)
+		}
+		r.GET("/", func(c *gin.Context) {
+			c.String(http.StatusOK, ClientIP(c))
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = tt.remoteAddr
+		if tt.header != "" {
+			req.Header.Set(tt.header, tt.value)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Body.String() != tt.want {
+			t.Errorf("proxies %v, %s from %s: client ip = %q, want %q", tt.proxies, tt.header, tt.remoteAddr, w.Body.String(), tt.want)
+		}
+	}
+
+	if err := SetTrustedProxies(gin.New(), []string{"not-an-ip"}); err == nil {
+		t.Errorf("invalid proxy should be rejected")
+	}
+}
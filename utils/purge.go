@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// PurgeBatchSize 清理软删除记录时每批物理删除的记录数
+var PurgeBatchSize = 1000
+
+// PurgeSoftDeleted 物理删除软删除时间早于 olderThan 之前的记录，用于数据保留策略，可由定时任务或管理接口调用，返回删除的记录数
+// 按主键分批删除，每批一条语句，避免长时间持有锁；删除完成后重新统计计数器
+// 模型需声明 deleted_at 列，整数时间戳按 softDelete 标签换算单位（默认秒，支持 milli、nano），也支持 gorm.DeletedAt 等时间类型
+func PurgeSoftDeleted(db *gorm.DB, model interface{}, olderThan time.Duration) (int64, error) {
+	// 新会话，避免传入的实例（如 Primary() 返回的实例）在多次查询间累积条件
+	db = db.Session(&gorm.Session{})
+
+	_, modelPtr, _ := GetModelInfo(model)
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(modelPtr); err != nil {
+		return 0, fmt.Errorf("failed to parse model %T: %v", model, err)
+	}
+	table := stmt.Schema.Table
+
+	field := stmt.Schema.LookUpField("deleted_at")
+	if field == nil {
+		return 0, fmt.Errorf("model %T has no deleted_at column", model)
+	}
+	pk := "id"
+	if stmt.Schema.PrioritizedPrimaryField != nil {
+		pk = stmt.Schema.PrioritizedPrimaryField.DBName
+	}
+
+	// 按字段类型生成软删除且早于截止时间的条件
+	cutoff := time.Now().Add(-olderThan)
+	condition := fmt.Sprintf("%s <> 0 AND %s < ?", field.DBName, field.DBName)
+	var value interface{}
+	switch kind := field.IndirectFieldType.Kind(); {
+	case kind >= reflect.Int && kind <= reflect.Uint64:
+		softDelete := strings.ToLower(field.TagSettings["SOFTDELETE"])
+		switch {
+		case strings.Contains(softDelete, "nano"):
+			value = cutoff.UnixNano()
+		case strings.Contains(softDelete, "milli"):
+			value = cutoff.UnixMilli()
+		default:
+			value = cutoff.Unix()
+		}
+	default:
+		condition = fmt.Sprintf("%s IS NOT NULL AND %s < ?", field.DBName, field.DBName)
+		value = cutoff
+	}
+
+	logger := GetLogger()
+	var purged int64
+	for {
+		var ids []interface{}
+		if err := db.Unscoped().Model(modelPtr).Where(condition, value).Order(pk).Limit(PurgeBatchSize).Pluck(pk, &ids).Error; err != nil {
+			return purged, fmt.Errorf("failed to query soft deleted records of %s: %v", table, err)
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		// 同时带上软删除条件，查询后被恢复的记录不会被删除
+		result := db.Unscoped().Where(fmt.Sprintf("%s IN ?", pk), ids).Where(condition, value).Delete(modelPtr)
+		if result.Error != nil {
+			return purged, fmt.Errorf("failed to purge soft deleted records of %s: %v", table, result.Error)
+		}
+		purged += result.RowsAffected
+
+		if len(ids) < PurgeBatchSize {
+			break
+		}
+	}
+
+	// 软删除的记录不计入计数器，清理后重新统计以校正可能存在的偏差
+	if err := RecountCounters(db, table); err != nil {
+		return purged, err
+	}
+
+	logger.Info("purged soft deleted records", zap.String("table", table), zap.Int64("purged", purged), zap.Time("cutoff", cutoff))
+	return purged, nil
+}
+
+// RecountCounters 按表中未软删除的记录重新统计计数器和登记的分组计数器，计数器表不存在时不做处理
+func RecountCounters(db *gorm.DB, tableName string) error {
+	db = db.Session(&gorm.Session{})
+	if !db.Migrator().HasTable("counters") {
+		return nil
+	}
+
+	var total int64
+	if err := db.Table(tableName).Where("deleted_at = 0").Count(&total).Error; err != nil {
+		return fmt.Errorf("failed to count %s: %v", tableName, err)
+	}
+	if err := db.Exec("UPDATE counters SET counter = ? WHERE name = ?", total, tableName).Error; err != nil {
+		return fmt.Errorf("failed to update counter %s: %v", tableName, err)
+	}
+
+	muCounterGroups.RLock()
+	columns := append([]string(nil), counterGroups[tableName]...)
+	muCounterGroups.RUnlock()
+
+	for _, column := range columns {
+		rows, err := db.Table(tableName).Select(fmt.Sprintf("%s, COUNT(*)", column)).Where("deleted_at = 0").Group(column).Rows()
+		if err != nil {
+			return fmt.Errorf("failed to count %s by %s: %v", tableName, column, err)
+		}
+		groups := make(map[string]int64)
+		for rows.Next() {
+			var value interface{}
+			var count int64
+			if err := rows.Scan(&value, &count); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to count %s by %s: %v", tableName, column, err)
+			}
+			switch v := value.(type) {
+			case nil:
+				groups[""] += count
+			case []byte:
+				groups[string(v)] += count
+			default:
+				groups[fmt.Sprint(v)] += count
+			}
+		}
+		rows.Close()
+
+		// 先清零该列的所有分组，再写入各分组的数量，已不存在的分组保持为 0
+		prefix := GroupCounterName(tableName, column, "")
+		if err := db.Exec("UPDATE counters SET counter = 0 WHERE name LIKE ?", prefix+"%").Error; err != nil {
+			return fmt.Errorf("failed to reset group counters %s: %v", prefix, err)
+		}
+		for value, count := range groups {
+			name := GroupCounterName(tableName, column, value)
+			result := db.Exec("UPDATE counters SET counter = ? WHERE name = ?", count, name)
+			if result.Error == nil && result.RowsAffected == 0 {
+				result = db.Exec("INSERT INTO counters (name, counter) VALUES (?, ?)", name, count)
+			}
+			if result.Error != nil {
+				return fmt.Errorf("failed to update group counter %s: %v", name, result.Error)
+			}
+		}
+	}
+	return nil
+}
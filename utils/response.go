@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 错误码
+const (
+	ErrCodeBadRequest           = "bad_request"            // 请求参数错误
+	ErrCodeInvalidBody          = "invalid_body"           // 请求体格式错误
+	ErrCodeUnsupportedMediaType = "unsupported_media_type" // 不支持的请求体类型
+	ErrCodeValidationFailed     = "validation_failed"      // 字段校验失败
+	ErrCodeNotFound             = "not_found"              // 资源不存在
+	ErrCodeConflict             = "conflict"               // 资源存在冲突
+	ErrCodeTooManyRequests      = "too_many_requests"      // 请求过多
+	ErrCodeTooLarge             = "too_large"              // 请求或响应过大
+	ErrCodeTimeout              = "timeout"                // 请求超时
+	ErrCodeDatabase             = "database_error"         // 数据库操作失败
+	ErrCodeUnavailable          = "unavailable"            // 服务暂不可用
+	ErrCodeInternal             = "internal_error"         // 服务内部错误
+)
+
+// ErrorResponse 统一错误响应
+type ErrorResponse struct {
+	Code    string            `json:"code"`              // 机器可读的错误码
+	Message string            `json:"message"`           // 错误信息
+	Fields  map[string]string `json:"fields,omitempty"`  // 字段校验错误，键为字段名，值为错误原因
+	Details []FieldError      `json:"details,omitempty"` // 字段校验错误的结构化信息
+}
+
+// ProblemDetails RFC 7807 错误响应，code 和 fields 为扩展字段
+type ProblemDetails struct {
+	Type     string            `json:"type"`
+	Title    string            `json:"title"`
+	Status   int               `json:"status"`
+	Detail   string            `json:"detail"`
+	Instance string            `json:"instance,omitempty"` // 链路追踪ID
+	Code     string            `json:"code"`
+	Fields   map[string]string `json:"fields,omitempty"`
+	Details  []FieldError      `json:"details,omitempty"`
+}
+
+// problemJSON 是否以 application/problem+json 格式返回错误
+var problemJSON atomic.Bool
+
+// SetProblemJSON 设置是否以 RFC 7807 application/problem+json 格式返回错误
+func SetProblemJSON(enabled bool) {
+	problemJSON.Store(enabled)
+}
+
+// SetStreaming 标记当前响应为流式输出，响应大小限制、压缩和事务等中间件不再缓存响应体，写入后直接发送给客户端
+func SetStreaming(c *gin.Context) {
+	c.Set("streaming", true)
+}
+
+// IsStreaming 判断当前响应是否为流式输出
+func IsStreaming(c *gin.Context) bool {
+	return c.GetBool("streaming")
+}
+
+// RespondError 返回统一格式的错误响应，资源注册时自定义了该状态码的错误信息时使用自定义信息
+func RespondError(c *gin.Context, status int, code, message string, fields map[string]string) {
+	respondError(c, status, code, message, fields, nil)
+}
+
+// RespondFieldErrors 返回字段校验错误响应，fields 为字段名到规则的映射，details 为每个字段的规则、参数和提交的值
+func RespondFieldErrors(c *gin.Context, status int, message string, fieldErrors []FieldError) {
+	respondError(c, status, ErrCodeValidationFailed, message, FieldErrorsMap(fieldErrors), fieldErrors)
+}
+
+// respondError 写入错误响应
+func respondError(c *gin.Context, status int, code, message string, fields map[string]string, details []FieldError) {
+	// 请求已超时时，处理程序因上下文取消产生的错误统一返回 504，响应写出后超时中间件无法再改写
+	if c.Request != nil && errors.Is(c.Request.Context().Err(), context.DeadlineExceeded) {
+		status, code, message, fields, details = http.StatusGatewayTimeout, ErrCodeTimeout, "request timeout", nil, nil
+	}
+
+	if messages, exists := c.Get("error_messages"); exists {
+		if custom, ok := messages.(map[int]string)[status]; ok {
+			message = custom
+		}
+	}
+
+	if problemJSON.Load() {
+		// gin 仅在未设置 Content-Type 时写入 application/json
+		c.Header("Content-Type", "application/problem+json")
+		c.JSON(status, ProblemDetails{
+			Type:     "about:blank",
+			Title:    http.StatusText(status),
+			Status:   status,
+			Detail:   message,
+			Instance: c.GetString(GetLogger().TraceIDKey()),
+			Code:     code,
+			Fields:   fields,
+			Details:  details,
+		})
+		return
+	}
+
+	c.JSON(status, gin.H{"error": ErrorResponse{
+		Code:    code,
+		Message: message,
+		Fields:  fields,
+		Details: details,
+	}})
+}
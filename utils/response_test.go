@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// validatedUser 校验错误测试使用的模型
+type validatedUser struct {
+	Name     string `json:"name" validate:"required"`
+	Password string `json:"-" validate:"min=8"`
+}
+
+// respondWith 在设置了 messages 的请求上下文中执行 respond，返回解析后的错误响应
+func respondWith(t *testing.T, messages map[int]string, respond func(c *gin.Context)) (int, map[string]interface{}) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	if messages != nil {
+		c.Set("error_messages", messages)
+	}
+	respond(c)
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid json response %q: %v", w.Body.String(), err)
+	}
+	return w.Code, body
+}
+
+func TestRespondErrorCustomMessages(t *testing.T) {
+	messages := map[int]string{http.StatusNotFound: "user not found", http.StatusUnprocessableEntity: "invalid user"}
+
+	// 自定义了状态码的错误信息时替换 message，错误码不变
+	status, body := respondWith(t, messages, func(c *gin.Context) {
+		RespondError(c, http.StatusNotFound, ErrCodeNotFound, "record not found", nil)
+	})
+	if err := body["error"].(map[string]interface{}); status != http.StatusNotFound || err["message"] != "user not found" || err["code"] != ErrCodeNotFound {
+		t.Fatalf("custom not found response: %d %v", status, body)
+	}
+
+	// 未自定义的状态码和未配置的资源使用默认信息
+	_, body = respondWith(t, messages, func(c *gin.Context) {
+		RespondError(c, http.StatusConflict, ErrCodeConflict, "record already exists", nil)
+	})
+	if err := body["error"].(map[string]interface{}); err["message"] != "record already exists" {
+		t.Fatalf("default conflict response: %v", body)
+	}
+	_, body = respondWith(t, nil, func(c *gin.Context) {
+		RespondError(c, http.StatusNotFound, ErrCodeNotFound, "record not found", nil)
+	})
+	if err := body["error"].(map[string]interface{}); err["message"] != "record not found" {
+		t.Fatalf("default not found response: %v", body)
+	}
+
+	// 字段校验错误替换 message，保留字段错误，敏感字段的值被替换
+	fieldErrors, err := ValidateStruct(&validatedUser{Password: "short"})
+	if err != nil || len(fieldErrors) != 2 {
+		t.Fatalf("field errors = %v, %v", fieldErrors, err)
+	}
+	status, body = respondWith(t, messages, func(c *gin.Context) {
+		RespondFieldErrors(c, http.StatusUnprocessableEntity, "validation failed", fieldErrors)
+	})
+	errBody := body["error"].(map[string]interface{})
+	fields := errBody["fields"].(map[string]interface{})
+	if status != http.StatusUnprocessableEntity || errBody["message"] != "invalid user" || errBody["code"] != ErrCodeValidationFailed ||
+		fields["name"] != "required" || fields["Password"] != "min=8" {
+		t.Fatalf("custom validation response: %d %v", status, body)
+	}
+	for _, detail := range errBody["details"].([]interface{}) {
+		if detail := detail.(map[string]interface{}); detail["field"] == "Password" && detail["value"] != redactedValue {
+			t.Fatalf("password value not redacted: %v", detail)
+		}
+	}
+
+	// problem+json 格式同样使用自定义信息
+	SetProblemJSON(true)
+	defer SetProblemJSON(false)
+	_, body = respondWith(t, messages, func(c *gin.Context) {
+		RespondError(c, http.StatusNotFound, ErrCodeNotFound, "record not found", nil)
+	})
+	if body["detail"] != "user not found" || body["code"] != ErrCodeNotFound {
+		t.Fatalf("custom problem response: %v", body)
+	}
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+// statementCounterKey 上下文中 SQL 语句计数器的键
+type statementCounterKey struct{}
+
+// WithStatementCounter 返回携带 SQL 语句计数器的上下文，使用该上下文执行的每条 SQL 语句都会计数
+func WithStatementCounter(ctx context.Context) (context.Context, *atomic.Int64) {
+	counter := &atomic.Int64{}
+	return context.WithValue(ctx, statementCounterKey{}, counter), counter
+}
+
+// registerStatementCounter 注册统计 SQL 语句数的回调，仅对携带计数器的上下文生效
+func registerStatementCounter(db *gorm.DB) error {
+	callback := db.Callback()
+	if err := callback.Create().After("*").Register("minigo:statement_counter", countStatement); err != nil {
+		return fmt.Errorf("failed to register statement counter: %v", err)
+	}
+	if err := callback.Query().After("*").Register("minigo:statement_counter", countStatement); err != nil {
+		return fmt.Errorf("failed to register statement counter: %v", err)
+	}
+	if err := callback.Update().After("*").Register("minigo:statement_counter", countStatement); err != nil {
+		return fmt.Errorf("failed to register statement counter: %v", err)
+	}
+	if err := callback.Delete().After("*").Register("minigo:statement_counter", countStatement); err != nil {
+		return fmt.Errorf("failed to register statement counter: %v", err)
+	}
+	if err := callback.Row().After("*").Register("minigo:statement_counter", countStatement); err != nil {
+		return fmt.Errorf("failed to register statement counter: %v", err)
+	}
+	if err := callback.Raw().After("*").Register("minigo:statement_counter", countStatement); err != nil {
+		return fmt.Errorf("failed to register statement counter: %v", err)
+	}
+	return nil
+}
+
+// countStatement 语句执行后计数，未生成 SQL（如空批量）或 DryRun 时不计数
+// 空批量在构建 SQL 时失败，此时 SQL 中可能残留部分语句，需按错误判断
+func countStatement(db *gorm.DB) {
+	if db.Statement.Context == nil || db.DryRun || db.Statement.SQL.Len() == 0 || errors.Is(db.Error, gorm.ErrEmptySlice) {
+		return
+	}
+	if counter, ok := db.Statement.Context.Value(statementCounterKey{}).(*atomic.Int64); ok {
+		counter.Add(1)
+	}
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestStatementCounter(t *testing.T) {
+	db := openTestDataBase(t)
+	if err := db.AutoMigrate(&counterItem{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	// 批量插入合并为一条语句
+	ctx, counter := WithStatementCounter(context.Background())
+	items := []counterItem{{Status: "a"}, {Status: "b"}, {Status: "c"}}
+	if err := db.WithContext(ctx).Create(&items).Error; err != nil {
+		t.Fatalf("failed to create records: %v", err)
+	}
+	if got := counter.Load(); got != 1 {
+		t.Fatalf("batched insert statements = %d, want 1", got)
+	}
+
+	// 逐条插入每条记录一条语句，查询同样计数
+	ctx, counter = WithStatementCounter(context.Background())
+	for _, status := range []string{"d", "e"} {
+		db.WithContext(ctx).Create(&counterItem{Status: status})
+	}
+	var count int64
+	db.WithContext(ctx).Model(&counterItem{}).Count(&count)
+	if got := counter.Load(); got != 3 || count != 5 {
+		t.Fatalf("statements = %d, count = %d, want 3 and 5", got, count)
+	}
+
+	// 未携带计数器的上下文不计数，空批量和 DryRun 不计数
+	db.Create(&counterItem{Status: "f"})
+	db.WithContext(ctx).Create(&[]counterItem{})
+	db.WithContext(ctx).Session(&gorm.Session{DryRun: true}).Create(&counterItem{Status: "g"})
+	if got := counter.Load(); got != 3 {
+		t.Fatalf("statements = %d after uncounted operations, want 3", got)
+	}
+}
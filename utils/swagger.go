@@ -1,27 +1,49 @@
 package utils
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"github.com/swaggo/swag"
+	"gopkg.in/yaml.v3"
 )
 
 // SwaggerInfo 存储 Swagger 文档的基本信息
 type SwaggerInfo struct {
-	Title       string
+	Title               string
+	Description         string
+	Version             string
+	BasePath            string
+	SecurityDefinitions map[string]SwaggerSecurityScheme // 可选的安全认证定义
+	Security            []string                         // 生成接口默认的安全要求，为安全认证定义的名称
+}
+
+// SwaggerSecurityScheme Swagger 安全认证定义，Bearer Token 使用 apiKey 类型并设置 Name 为 Authorization
+type SwaggerSecurityScheme struct {
+	Type        string // basic 或 apiKey
+	Name        string // apiKey 的参数名
+	In          string // apiKey 的位置，header 或 query
 	Description string
-	Version     string
-	BasePath    string
 }
 
 // GenericSwaggerGenerator 用于生成通用 API 的 Swagger 文档
 type GenericSwaggerGenerator struct {
-	info SwaggerInfo
+	info        SwaggerInfo
+	paths       []string // 已生成的各资源路径定义
+	definitions []string // 已生成的各模型定义
+	mu          sync.RWMutex
+	register    sync.Once
 }
 
 // NewSwaggerGenerator 创建一个新的 Swagger 生成器实例
@@ -31,8 +53,9 @@ func NewSwaggerGenerator(info SwaggerInfo) *GenericSwaggerGenerator {
 	}
 }
 
-// GenerateSwaggerDocs 为给定的模型生成 Swagger 文档
-func (g *GenericSwaggerGenerator) GenerateSwaggerDocs(resourceName string, model interface{}) {
+// GenerateSwaggerDocs 为给定的模型生成 Swagger 文档，多次调用会合并到同一份文档中
+// security 为该资源接口的安全要求，未指定时使用 SwaggerInfo.Security
+func (g *GenericSwaggerGenerator) GenerateSwaggerDocs(resourceName string, model interface{}, security ...string) {
 	modelType := reflect.TypeOf(model)
 	if modelType.Kind() == reflect.Ptr {
 		modelType = modelType.Elem()
@@ -41,13 +64,29 @@ func (g *GenericSwaggerGenerator) GenerateSwaggerDocs(resourceName string, model
 	// 生成模型定义
 	modelSchema := g.generateModelSchema(modelType)
 
-	// 注册 Swagger 信息
-	swag.Register(swag.Name, &swag.Spec{
-		InfoInstanceName: swag.Name,
-		SwaggerTemplate:  g.generateSwaggerTemplate(resourceName, modelType.Name(), modelSchema, modelType),
+	// 累积路径和模型定义
+	g.mu.Lock()
+	if len(security) == 0 {
+		security = g.info.Security
+	}
+	g.paths = append(g.paths, g.attachSecurity(g.generatePaths(resourceName, modelType.Name(), modelType), security))
+	g.definitions = append(g.definitions, g.generateDefinitions(modelType.Name(), modelSchema, modelType))
+	g.mu.Unlock()
+
+	// 注册 Swagger 信息，swag 只允许注册一次，文档在读取时合并生成
+	g.register.Do(func() {
+		swag.Register(swag.Name, g)
 	})
 }
 
+// ReadDoc 实现 swag.Swagger 接口，返回合并后的 Swagger 文档
+func (g *GenericSwaggerGenerator) ReadDoc() string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.generateSwaggerTemplate()
+}
+
 // generateModelSchema 生成模型的 Schema 定义
 func (g *GenericSwaggerGenerator) generateModelSchema(modelType reflect.Type) string {
 	var properties []string
@@ -66,9 +105,6 @@ func (g *GenericSwaggerGenerator) generateModelSchema(modelType reflect.Type) st
 			fieldName = field.Name
 		}
 
-		// 获取字段类型
-		fieldType := g.convertGoTypeToSwaggerType(field.Type)
-
 		// 获取字段描述
 		description := field.Tag.Get("description")
 		if description == "" {
@@ -81,18 +117,23 @@ func (g *GenericSwaggerGenerator) generateModelSchema(modelType reflect.Type) st
 			// 构建属性定义
 			property = fmt.Sprintf(`
           %s:
-            type: %s
-            description: "%s"`, fieldName, fieldType, description)
+%s
+            description: "%s"`, fieldName, g.generatePropertyAttributes(field, "            "), description)
 		} else {
 			property = `
           id:
             type: integer
+            example: 1
             description: "Resource ID"
           created_at:
             type: integer
+            format: int64
+            example: 1735660800000
             description: "Create timestamp"
           updated_at:
             type: integer
+            format: int64
+            example: 1735660800000
             description: "Update timestamp"`
 		}
 
@@ -102,8 +143,163 @@ func (g *GenericSwaggerGenerator) generateModelSchema(modelType reflect.Type) st
 	return strings.Join(properties, "\n")
 }
 
+// generatePropertyAttributes 生成字段的类型、格式、长度限制、示例和枚举，indent 为每行的缩进
+func (g *GenericSwaggerGenerator) generatePropertyAttributes(field reflect.StructField, indent string) string {
+	attributes := []string{fmt.Sprintf("%stype: %s", indent, g.convertGoTypeToSwaggerType(field.Type))}
+
+	if format := g.convertGoTypeToSwaggerFormat(field.Type); format != "" {
+		attributes = append(attributes, fmt.Sprintf("%sformat: %s", indent, format))
+	}
+
+	if maxLength := getStringMaxLength(field); maxLength > 0 {
+		attributes = append(attributes, fmt.Sprintf("%smaxLength: %d", indent, maxLength))
+	}
+
+	if example, err := json.Marshal(g.generateExampleValue(field)); err == nil {
+		attributes = append(attributes, fmt.Sprintf("%sexample: %s", indent, example))
+	}
+
+	if enum := getSwaggerTagOption(field, "enum"); enum != "" {
+		var values []string
+		for _, value := range strings.Split(enum, "|") {
+			if g.convertGoTypeToSwaggerType(field.Type) == "string" {
+				value = fmt.Sprintf(`"%s"`, value)
+			}
+			values = append(values, value)
+		}
+		attributes = append(attributes, fmt.Sprintf("%senum: [%s]", indent, strings.Join(values, ", ")))
+	}
+
+	return strings.Join(attributes, "\n")
+}
+
+// generateExampleValue 生成字段的示例值，优先使用 example 标签，其次使用枚举的第一个值，否则按类型生成
+func (g *GenericSwaggerGenerator) generateExampleValue(field reflect.StructField) interface{} {
+	example := field.Tag.Get("example")
+	if example == "" {
+		if enum := getSwaggerTagOption(field, "enum"); enum != "" {
+			example = strings.Split(enum, "|")[0]
+		}
+	}
+
+	switch g.convertGoTypeToSwaggerType(field.Type) {
+	case "integer":
+		if v, err := strconv.ParseInt(example, 10, 64); err == nil {
+			return v
+		}
+		return 0
+	case "number":
+		if v, err := strconv.ParseFloat(example, 64); err == nil {
+			return v
+		}
+		return 0.0
+	case "boolean":
+		if v, err := strconv.ParseBool(example); err == nil {
+			return v
+		}
+		return false
+	case "string":
+		if example != "" {
+			return example
+		}
+		return "string"
+	case "array":
+		return []interface{}{}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// createFieldName 获取字段在创建请求体中的名称，创建时不可提交的字段返回空字符串
+// 声明了可创建字段时只包含可创建的字段，否则与可更新字段一致
+func createFieldName(field reflect.StructField, creatableFields []string) string {
+	tag := field.Tag.Get("ctags")
+	if tag == "" {
+		return ""
+	}
+
+	fieldName := strings.Split(tag, ",")[0]
+	fieldTags := strings.Split(tag, ",")[1:]
+
+	if creatableFields != nil {
+		if name := BindFieldName(field); ExistsIn(creatableFields, name) {
+			return name
+		}
+		return ""
+	}
+	if fieldName != "" && ExistsIn(fieldTags, "u") && !ExistsIn(fieldTags, "wo") && field.Tag.Get("json") != "-" {
+		return fieldName
+	}
+	return ""
+}
+
+// generateCreateExample 生成创建接口的请求体示例
+func (g *GenericSwaggerGenerator) generateCreateExample(modelType reflect.Type) string {
+	example := make(map[string]interface{})
+
+	creatableFields := CreatableFields(modelType)
+	for _, field := range StructFields(modelType) {
+		if name := createFieldName(field, creatableFields); name != "" {
+			example[name] = g.generateExampleValue(field)
+		}
+	}
+
+	data, err := json.Marshal(example)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+// generateCreateSchema 生成创建时可提交字段的 Schema
+func (g *GenericSwaggerGenerator) generateCreateSchema(modelType reflect.Type) string {
+	var properties []string
+
+	creatableFields := CreatableFields(modelType)
+	for _, field := range StructFields(modelType) {
+		name := createFieldName(field, creatableFields)
+		if name == "" {
+			continue
+		}
+
+		description := field.Tag.Get("description")
+		if description == "" {
+			description = name
+		}
+		properties = append(properties, fmt.Sprintf(`      %s:
+%s
+        description: "%s"`, name, g.generatePropertyAttributes(field, "        "), description))
+	}
+
+	return strings.Join(properties, "\n")
+}
+
+// getSwaggerTagOption 获取 swagger 标签中的选项，标签形如 swagger:"enum=active|inactive|banned"，多个选项用逗号分隔
+func getSwaggerTagOption(field reflect.StructField, name string) string {
+	tag := field.Tag.Get("swagger")
+	if tag == "" {
+		return ""
+	}
+
+	for _, option := range strings.Split(tag, ",") {
+		if key, value, found := strings.Cut(option, "="); found && strings.TrimSpace(key) == name {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
 // convertGoTypeToSwaggerType 将 Go 类型转换为 Swagger 类型
 func (g *GenericSwaggerGenerator) convertGoTypeToSwaggerType(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// []byte 按 base64 字符串处理，时间按 RFC3339 字符串处理
+	if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 || t == reflect.TypeOf(time.Time{}) {
+		return "string"
+	}
+
 	switch t.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
 		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
@@ -121,8 +317,59 @@ func (g *GenericSwaggerGenerator) convertGoTypeToSwaggerType(t reflect.Type) str
 	}
 }
 
+// convertGoTypeToSwaggerFormat 将 Go 类型转换为 Swagger 格式
+func (g *GenericSwaggerGenerator) convertGoTypeToSwaggerFormat(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if t.Bits() == 64 {
+			return "int64"
+		}
+		return "int32"
+	case reflect.Float32:
+		return "float"
+	case reflect.Float64:
+		return "double"
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "byte"
+		}
+	case reflect.Struct:
+		if t == reflect.TypeOf(time.Time{}) {
+			return "date-time"
+		}
+	}
+	return ""
+}
+
+// getStringMaxLength 从 gorm 标签的 type:varchar(n) 或 size:n 获取字符串最大长度
+func getStringMaxLength(field reflect.StructField) int {
+	if field.Type.Kind() != reflect.String {
+		return 0
+	}
+
+	tag := field.Tag.Get("gorm")
+	for _, pattern := range []*regexp.Regexp{varcharPattern, sizePattern} {
+		if match := pattern.FindStringSubmatch(tag); len(match) > 1 {
+			if length, err := strconv.Atoi(match[1]); err == nil {
+				return length
+			}
+		}
+	}
+	return 0
+}
+
+var (
+	varcharPattern = regexp.MustCompile(`(?i)type:(?:var)?char\((\d+)\)`)
+	sizePattern    = regexp.MustCompile(`(?i)size:(\d+)`)
+)
+
 // generateSwaggerTemplate 生成完整的 Swagger 模板
-func (g *GenericSwaggerGenerator) generateSwaggerTemplate(resourceName, modelName string, modelSchema string, modelType reflect.Type) string {
+func (g *GenericSwaggerGenerator) generateSwaggerTemplate() string {
 	return fmt.Sprintf(`
 swagger: "2.0"
 info:
@@ -138,9 +385,70 @@ consumes:
   - application/x-www-form-urlencoded
 produces:
   - application/json
-
+%s
 paths:
-  /%s:
+%s
+definitions:
+%s`,
+		g.info.Title,                    // 1
+		g.info.Description,              // 2
+		g.info.Version,                  // 3
+		g.info.BasePath,                 // 4
+		g.generateSecurityDefinitions(), // 5
+		strings.Join(g.paths, ""),       // 6
+		strings.Join(g.definitions, ""), // 7
+	)
+}
+
+// generateSecurityDefinitions 生成安全认证定义
+func (g *GenericSwaggerGenerator) generateSecurityDefinitions() string {
+	if len(g.info.SecurityDefinitions) == 0 {
+		return ""
+	}
+
+	// 按名称排序，保证文档输出稳定
+	names := make([]string, 0, len(g.info.SecurityDefinitions))
+	for name := range g.info.SecurityDefinitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	definitions := []string{"securityDefinitions:"}
+	for _, name := range names {
+		scheme := g.info.SecurityDefinitions[name]
+		definitions = append(definitions, fmt.Sprintf("  %s:\n    type: %s", name, scheme.Type))
+		if scheme.Name != "" {
+			definitions = append(definitions, fmt.Sprintf("    name: %s", scheme.Name))
+		}
+		if scheme.In != "" {
+			definitions = append(definitions, fmt.Sprintf("    in: %s", scheme.In))
+		}
+		if scheme.Description != "" {
+			definitions = append(definitions, fmt.Sprintf(`    description: "%s"`, scheme.Description))
+		}
+	}
+
+	return strings.Join(definitions, "\n") + "\n"
+}
+
+// attachSecurity 为资源的每个接口添加安全要求
+func (g *GenericSwaggerGenerator) attachSecurity(paths string, security []string) string {
+	if len(security) == 0 {
+		return paths
+	}
+
+	requirements := []string{"\n      security:"}
+	for _, name := range security {
+		requirements = append(requirements, fmt.Sprintf("        - %s: []", name))
+	}
+
+	// 每个接口都有且只有一个 responses 段，安全要求插入在其之前
+	return strings.ReplaceAll(paths, "\n      responses:", strings.Join(requirements, "\n")+"\n      responses:")
+}
+
+// generatePaths 生成单个资源的路径定义
+func (g *GenericSwaggerGenerator) generatePaths(resourceName, modelName string, modelType reflect.Type) string {
+	return fmt.Sprintf(`  /%s:
     get:
       summary: List %s
       description: Get a paginated list of %s
@@ -159,10 +467,39 @@ paths:
           name: search
           type: string
           description: Search term
+        - in: query
+          name: search_fields
+          type: string
+          description: Comma-separated queryable fields to search, defaults to all string fields
+        - in: query
+          name: search_mode
+          type: string
+          enum: ["contains", "prefix"]
+          default: contains
+          description: Match anywhere in the field, or only at the start (prefix can use indexes)
+        - in: query
+          name: deleted
+          type: string
+          enum: ["only", "all"]
+          description: Include soft-deleted records, only them or all records
+        - in: query
+          name: exact_count
+          type: boolean
+          description: Count the total with COUNT(*) instead of the counter table
+        - in: query
+          name: format
+          type: string
+          enum: [json, csv]
+          description: Export as CSV (same as Accept text/csv), all matching records are exported unless page_size is set
+        - in: query
+          name: links
+          type: boolean
+          description: Include first, prev, next and last page links built from the current query
         - in: query
           name: order
           type: string
           description: Order by field (prefix with - for desc)
+          enum: [%s]%s
       responses:
         200:
           description: Successful operation
@@ -175,6 +512,18 @@ paths:
                 type: integer
               page_size:
                 type: integer
+              links:
+                type: object
+                description: Page links, only present with links=true, missing links are null
+                properties:
+                  first:
+                    type: string
+                  prev:
+                    type: string
+                  next:
+                    type: string
+                  last:
+                    type: string
               data:
                 type: array
                 items:
@@ -187,14 +536,41 @@ paths:
           name: body
           required: true
           schema:
-            type: array
-            items:
-              $ref: "#/definitions/%sSingleUpdate"
+            $ref: "#/definitions/%sCreate"
+        - in: query
+          name: dry_run
+          type: boolean
+          description: Validate the request and return what would be written without writing
+        - in: query
+          name: on_conflict
+          type: string
+          enum: [error, ignore, update]
+          description: Action on primary or unique key conflict, ignore keeps the existing record, update overwrites it
+        - in: query
+          name: conflict_columns
+          type: string
+          description: Comma separated unique columns used to detect conflicts, required for ignore and update
+        - in: query
+          name: update_columns
+          type: string
+          description: Comma separated updatable columns to overwrite on conflict, defaults to the updatable fields in the request
+        - in: header
+          name: Idempotency-Key
+          type: string
+          description: Repeated requests with the same key return the originally created record
       responses:
         201:
           description: Successfully created
+          headers:
+            Location:
+              type: string
+              description: URL of the created record, only set when a single record is created
           schema:
             $ref: "#/definitions/%s"
+        400:
+          description: Malformed request body
+        422:
+          description: Field validation failed, or idempotency key reused with a different request
     delete:
       summary: Batch Delete %s
       description: Delete multiple %s by IDs
@@ -221,6 +597,13 @@ paths:
             properties:
               message:
                 type: string
+              not_found:
+                type: array
+                description: IDs that do not exist
+                items:
+                  type: integer
+        404:
+          description: None of the IDs exist
     put:
       summary: Batch Update %s
       description: Update multiple %s
@@ -235,6 +618,10 @@ paths:
                 type: array
                 items:
                   $ref: "#/definitions/%sBatchUpdate"
+        - in: query
+          name: dry_run
+          type: boolean
+          description: Validate the request and return what would be written without writing
       responses:
         200:
           description: Successfully updated
@@ -243,6 +630,47 @@ paths:
             properties:
               message:
                 type: string
+              updated:
+                type: array
+                description: IDs of the updated records
+                items:
+                  type: integer
+              not_found:
+                type: array
+                description: IDs that do not exist
+                items:
+                  type: integer
+        400:
+          description: Malformed request body
+        409:
+          description: A record has been modified since the version in the request
+        422:
+          description: Field validation failed
+    patch:
+      summary: Update %s by filter
+      description: Update all %s matching the query filters, or by objs like PUT. At least one filter is required, unknown filters are rejected
+      parameters:%s
+        - in: body
+          name: body
+          required: true
+          schema:
+            $ref: "#/definitions/%sSingleUpdate"
+        - in: query
+          name: dry_run
+          type: boolean
+          description: Validate the request and return what would be written without writing
+      responses:
+        200:
+          description: Successfully updated
+          schema:
+            type: object
+            properties:
+              message:
+                type: string
+              affected:
+                type: integer
+        400:
+          description: Missing or invalid filters
     
   /%s/{id}:
     get:
@@ -254,11 +682,21 @@ paths:
           required: true
           type: integer
           description: ID of the %s
+        - in: header
+          name: If-None-Match
+          type: string
+          description: ETag from a previous response, returns 304 when the record is unchanged
       responses:
         200:
           description: Successful operation
+          headers:
+            ETag:
+              type: string
+              description: Version of the record
           schema:
             $ref: "#/definitions/%s"
+        304:
+          description: Record not modified
     put:
       summary: Update %s
       description: Update an existing %s
@@ -273,6 +711,10 @@ paths:
           required: true
           schema:
             $ref: "#/definitions/%sSingleUpdate"
+        - in: query
+          name: dry_run
+          type: boolean
+          description: Validate the request and return what would be written without writing
       responses:
         200:
           description: Successfully updated
@@ -281,6 +723,16 @@ paths:
             properties:
               message:
                 type: string
+              affected:
+                type: integer
+        400:
+          description: Malformed request body
+        404:
+          description: Resource not found
+        409:
+          description: The record has been modified since the version in the request
+        422:
+          description: Field validation failed
     delete:
       summary: Delete %s
       description: Delete a %s by ID
@@ -298,9 +750,48 @@ paths:
             properties:
               message:
                 type: string
+              affected:
+                type: integer
+        404:
+          description: Resource not found
+`,
+		resourceName, // 1
+		modelName,    // 2
+		modelName,    // 3
+		strings.Join(g.generateOrderEnum(modelType), ", "), // 4
+		g.generateQueryParameters(modelType),               // 5
+		modelName,                                          // 6
+		modelName,                                          // 7
+		modelName,                                          // 8
+		modelName,                                          // 9
+		modelName,                                          // 10
+		modelName,                                          // 11
+		modelName,                                          // 12
+		modelName,                                          // 13
+		modelName,                                          // 14
+		modelName,                                          // 15
+		modelName,                                          // 16
+		modelName,                                          // 17
+		g.generateQueryParameters(modelType),               // 18
+		modelName,                                          // 19
+		resourceName,                                       // 20
+		modelName,                                          // 21
+		modelName,                                          // 22
+		modelName,                                          // 23
+		modelName,                                          // 24
+		modelName,                                          // 25
+		modelName,                                          // 26
+		modelName,                                          // 27
+		modelName,                                          // 28
+		modelName,                                          // 29
+		modelName,                                          // 30
+		modelName,                                          // 31
+	)
+}
 
-definitions:
-  %s:
+// generateDefinitions 生成单个模型的定义
+func (g *GenericSwaggerGenerator) generateDefinitions(modelName string, modelSchema string, modelType reflect.Type) string {
+	return fmt.Sprintf(`  %s:
     type: object
     properties:%s
   %sSingleUpdate:
@@ -313,45 +804,81 @@ definitions:
     description: Fields that can be updated
     properties:
 %s
+  %sCreate:
+    type: object
+    description: Fields that can be set on create. The endpoint also accepts a JSON array of such objects to create records in batch
+    properties:
+%s
+    example: %s
 `,
-		g.info.Title,                            // 1
-		g.info.Description,                      // 2
-		g.info.Version,                          // 3
-		g.info.BasePath,                         // 4
-		resourceName,                            // 5
-		modelName,                               // 6
+		modelName,                               // 1
+		modelSchema,                             // 2
+		modelName,                               // 3
+		g.generateSingleUpdateSchema(modelType), // 4
+		modelName,                               // 5
+		g.generateBatchUpdateSchema(modelType),  // 6
 		modelName,                               // 7
-		modelName,                               // 8
-		modelName,                               // 9
-		modelName,                               // 10
-		modelName,                               // 11
-		modelName,                               // 12
-		modelName,                               // 13
-		modelName,                               // 14
-		modelName,                               // 15
-		modelName,                               // 16
-		modelName,                               // 17
-		resourceName,                            // 18
-		modelName,                               // 19
-		modelName,                               // 20
-		modelName,                               // 21
-		modelName,                               // 22
-		modelName,                               // 23
-		modelName,                               // 24
-		modelName,                               // 25
-		modelName,                               // 26
-		modelName,                               // 27
-		modelName,                               // 28
-		modelName,                               // 29
-		modelName,                               // 30
-		modelSchema,                             // 31
-		modelName,                               // 32
-		g.generateSingleUpdateSchema(modelType), // 33
-		modelName,                               // 34
-		g.generateBatchUpdateSchema(modelType),  // 35
+		g.generateCreateSchema(modelType),       // 8
+		g.generateCreateExample(modelType),      // 9
 	)
 }
 
+// generateOrderEnum 生成排序参数的可选值，来自 ctags 的 o 标签
+func (g *GenericSwaggerGenerator) generateOrderEnum(modelType reflect.Type) []string {
+	orderFields := []string{"id"}
+
+	for _, field := range StructFields(modelType) {
+		tag := field.Tag.Get("ctags")
+		if tag != "" {
+			fieldName := strings.Split(tag, ",")[0]
+			fieldTags := strings.Split(tag, ",")[1:]
+			if fieldName != "" && ExistsIn(fieldTags, "o") {
+				orderFields = append(orderFields, fieldName)
+			}
+		}
+	}
+
+	var enum []string
+	for _, field := range orderFields {
+		enum = append(enum, fmt.Sprintf(`"%s"`, field), fmt.Sprintf(`"-%s"`, field))
+	}
+	return enum
+}
+
+// generateQueryParameters 生成列表查询的过滤参数，来自 ctags 的 q 标签
+func (g *GenericSwaggerGenerator) generateQueryParameters(modelType reflect.Type) string {
+	var parameters []string
+
+	for _, field := range StructFields(modelType) {
+		tag := field.Tag.Get("ctags")
+		if tag == "" {
+			continue
+		}
+
+		fieldName := strings.Split(tag, ",")[0]
+		fieldTags := strings.Split(tag, ",")[1:]
+		if fieldName == "" || !ExistsIn(fieldTags, "q") || ExistsIn(fieldTags, "encrypt") {
+			continue
+		}
+
+		// 精确查询
+		parameters = append(parameters, fmt.Sprintf(`
+        - in: query
+          name: %s
+          type: %s
+          description: Filter by %s (exact match)`, fieldName, g.convertGoTypeToSwaggerType(field.Type), fieldName))
+
+		// 模糊查询
+		parameters = append(parameters, fmt.Sprintf(`
+        - in: query
+          name: %s_contains
+          type: string
+          description: Filter by %s (contains)`, fieldName, fieldName))
+	}
+
+	return strings.Join(parameters, "")
+}
+
 // generateBatchUpdateSchema 生成可更新字段的 Schema
 func (g *GenericSwaggerGenerator) generateBatchUpdateSchema(modelType reflect.Type) string {
 	var properties []string
@@ -362,64 +889,127 @@ func (g *GenericSwaggerGenerator) generateBatchUpdateSchema(modelType reflect.Ty
         type: integer
         description: "Resource ID"`)
 
-	for i := 0; i < modelType.NumField(); i++ {
-		field := modelType.Field(i)
+	for _, field := range StructFields(modelType) {
 		tag := field.Tag.Get("ctags")
 
 		if tag != "" {
 			fieldName := strings.Split(tag, ",")[0]
 			fieldTags := strings.Split(tag, ",")[1:]
 
-			if fieldName != "" && ExistsIn(fieldTags, "u") {
-				fieldType := g.convertGoTypeToSwaggerType(field.Type)
+			if fieldName != "" && ExistsIn(fieldTags, "u") && !ExistsIn(fieldTags, "wo") && field.Tag.Get("json") != "-" {
 				description := field.Tag.Get("description")
 				if description == "" {
 					description = fieldName
 				}
 
 				property := fmt.Sprintf(`      %s:
-        type: %s
-        description: "%s"`, fieldName, fieldType, description)
+%s
+        description: "%s"`, fieldName, g.generatePropertyAttributes(field, "        "), description)
 				properties = append(properties, property)
 			}
 		}
 	}
+	if property := g.generateVersionProperty(modelType); property != "" {
+		properties = append(properties, property)
+	}
 
 	return strings.Join(properties, "\n")
 }
 
+// generateVersionProperty 生成乐观锁版本字段的属性，模型没有版本字段或版本字段已作为可更新字段列出时返回空字符串
+func (g *GenericSwaggerGenerator) generateVersionProperty(modelType reflect.Type) string {
+	field, ok := VersionField(modelType)
+	if !ok || ExistsIn(strings.Split(field.Tag.Get("ctags"), ",")[1:], "u") {
+		return ""
+	}
+	return fmt.Sprintf(`      %s:
+        type: integer
+        description: "Last known version, the update fails with 409 when the record has been modified"`, JSONFieldName(field))
+}
+
 // generateSingleUpdateSchema 生成可更新字段的 Schema
 func (g *GenericSwaggerGenerator) generateSingleUpdateSchema(modelType reflect.Type) string {
 	var properties []string
 
-	for i := 0; i < modelType.NumField(); i++ {
-		field := modelType.Field(i)
+	for _, field := range StructFields(modelType) {
 		tag := field.Tag.Get("ctags")
 
 		if tag != "" {
 			fieldName := strings.Split(tag, ",")[0]
 			fieldTags := strings.Split(tag, ",")[1:]
 
-			if fieldName != "" && ExistsIn(fieldTags, "u") {
-				fieldType := g.convertGoTypeToSwaggerType(field.Type)
+			if fieldName != "" && ExistsIn(fieldTags, "u") && !ExistsIn(fieldTags, "wo") && field.Tag.Get("json") != "-" {
 				description := field.Tag.Get("description")
 				if description == "" {
 					description = fieldName
 				}
 
 				property := fmt.Sprintf(`      %s:
-        type: %s
-        description: "%s"`, fieldName, fieldType, description)
+%s
+        description: "%s"`, fieldName, g.generatePropertyAttributes(field, "        "), description)
 				properties = append(properties, property)
 			}
 		}
 	}
+	if property := g.generateVersionProperty(modelType); property != "" {
+		properties = append(properties, property)
+	}
 
 	return strings.Join(properties, "\n")
 }
 
-// RegisterSwaggerRoute 注册 Swagger UI 路由
+// ReadDocJSON 返回 JSON 格式的 Swagger 文档
+func (g *GenericSwaggerGenerator) ReadDocJSON() ([]byte, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal([]byte(g.ReadDoc()), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse swagger document: %v", err)
+	}
+	return json.Marshal(normalizeYAMLValue(doc))
+}
+
+// normalizeYAMLValue 将 YAML 解析出的非字符串键（如响应码 200）转换为字符串键，以便序列化为 JSON
+func normalizeYAMLValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[fmt.Sprint(key)] = normalizeYAMLValue(item)
+		}
+		return result
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeYAMLValue(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeYAMLValue(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// RegisterSwaggerRoute 注册 Swagger UI 路由，以及原始文档路由 /swagger/doc.json 和 /swagger/doc.yaml
 func (g *GenericSwaggerGenerator) RegisterSwaggerRoute(r *gin.Engine) {
 	// 需要先安装 gin-swagger
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	handler := ginSwagger.WrapHandler(swaggerFiles.Handler)
+
+	// gin 不允许通配路由与同级静态路由共存，原始文档在通配路由中分发
+	r.GET("/swagger/*any", func(c *gin.Context) {
+		switch c.Param("any") {
+		case "/doc.json":
+			doc, err := g.ReadDocJSON()
+			if err != nil {
+				RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "internal server error", nil)
+				return
+			}
+			c.Data(http.StatusOK, "application/json; charset=utf-8", doc)
+		case "/doc.yaml":
+			c.Data(http.StatusOK, "application/yaml; charset=utf-8", []byte(g.ReadDoc()))
+		default:
+			handler(c)
+		}
+	})
 }
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// swaggerItem Swagger 测试使用的模型，name、code 和只写的 secret 可创建，status 只能更新且取值为枚举
+type swaggerItem struct {
+	ID     uint   `json:"id" gorm:"primarykey"`
+	Name   string `json:"name" ctags:"name,q,c,u"`
+	Code   string `json:"code" ctags:"code,wo"`
+	Status string `json:"status" ctags:"status,u" swagger:"enum=active|inactive"`
+	Level  int    `json:"level" swagger:"enum=1|2|3"`
+	Secret string `json:"-" ctags:"secret,c,u"`
+}
+
+func TestSwaggerCreateSchema(t *testing.T) {
+	g := NewSwaggerGenerator(SwaggerInfo{Title: "test", Version: "1.0", BasePath: "/api"})
+	modelType := reflect.TypeOf(swaggerItem{})
+	g.paths = append(g.paths, g.generatePaths("swagger_items", modelType.Name(), modelType))
+	g.definitions = append(g.definitions, g.generateDefinitions(modelType.Name(), g.generateModelSchema(modelType), modelType))
+
+	data, err := g.ReadDocJSON()
+	if err != nil {
+		t.Fatalf("invalid swagger document: %v", err)
+	}
+	var doc struct {
+		Definitions map[string]struct {
+			Type       string                     `json:"type"`
+			Properties map[string]json.RawMessage `json:"properties"`
+			Example    json.RawMessage            `json:"example"`
+		} `json:"definitions"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("failed to decode swagger document: %v", err)
+	}
+
+	// 创建请求体是由可创建字段组成的对象
+	schema, ok := doc.Definitions["swaggerItemCreate"]
+	if !ok || schema.Type != "object" {
+		t.Fatalf("create schema must be an object: %+v", schema)
+	}
+	var properties []string
+	for name := range schema.Properties {
+		properties = append(properties, name)
+	}
+	sort.Strings(properties)
+	if !reflect.DeepEqual(properties, []string{"code", "name", "secret"}) {
+		t.Fatalf("create properties = %v, want [code name secret]", properties)
+	}
+
+	// 枚举值写入模型定义，字符串类型的枚举值加引号
+	enums := map[string]string{"status": `["active","inactive"]`, "level": `[1,2,3]`}
+	for name, want := range enums {
+		var property struct {
+			Enum json.RawMessage `json:"enum"`
+		}
+		if err := json.Unmarshal(doc.Definitions["swaggerItem"].Properties[name], &property); err != nil || string(property.Enum) != want {
+			t.Fatalf("%s enum = %s, want %s", name, property.Enum, want)
+		}
+	}
+
+	var example map[string]interface{}
+	if err := json.Unmarshal(schema.Example, &example); err != nil || len(example) != 3 {
+		t.Fatalf("create example must be an object of creatable fields: %s", schema.Example)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build linux
+
+package utils
+
+import "syscall"
+
+// getThreadID 获取当前线程ID
+func getThreadID() uint64 {
+	return uint64(syscall.Gettid())
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package utils
+
+// getThreadID 获取当前线程ID，非 Linux 平台无可移植的获取方式，返回 0
+func getThreadID() uint64 {
+	return 0
+}
@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TypeScriptGenerator 根据模型生成 TypeScript 类型定义
+type TypeScriptGenerator struct {
+	interfaces []string // 已生成的接口定义
+	names      []string // 已生成的接口名称
+	mu         sync.RWMutex
+}
+
+// NewTypeScriptGenerator 创建一个新的 TypeScript 类型生成器实例
+func NewTypeScriptGenerator() *TypeScriptGenerator {
+	return &TypeScriptGenerator{}
+}
+
+// GenerateTypes 为给定的模型生成 TypeScript 接口，多次调用会合并到同一份文件中
+func (g *TypeScriptGenerator) GenerateTypes(model interface{}) {
+	modelType := reflect.TypeOf(model)
+	if modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.generateInterface(modelType)
+}
+
+// ReadTypes 返回合并后的 TypeScript 类型定义
+func (g *TypeScriptGenerator) ReadTypes() string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return "// Code generated by minigo. DO NOT EDIT.\n\n" + strings.Join(g.interfaces, "\n")
+}
+
+// WriteFile 将 TypeScript 类型定义写入文件
+func (g *TypeScriptGenerator) WriteFile(path string) error {
+	if err := os.WriteFile(path, []byte(g.ReadTypes()), 0644); err != nil {
+		return fmt.Errorf("failed to write typescript file: %v", err)
+	}
+	return nil
+}
+
+// RegisterTypeScriptRoute 注册 TypeScript 类型定义路由
+func (g *TypeScriptGenerator) RegisterTypeScriptRoute(r *gin.Engine) {
+	r.GET("/clients/types.ts", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/typescript; charset=utf-8", []byte(g.ReadTypes()))
+	})
+}
+
+// generateInterface 生成模型的接口定义，嵌套的具名结构体会一并生成
+func (g *TypeScriptGenerator) generateInterface(modelType reflect.Type) {
+	if ExistsIn(g.names, modelType.Name()) {
+		return
+	}
+	g.names = append(g.names, modelType.Name())
+
+	var properties []string
+	g.collectProperties(modelType, &properties)
+
+	g.interfaces = append(g.interfaces, fmt.Sprintf("export interface %s {\n%s\n}\n",
+		modelType.Name(), strings.Join(properties, "\n")))
+}
+
+// collectProperties 收集结构体字段，匿名嵌入的结构体字段会被展开
+func (g *TypeScriptGenerator) collectProperties(modelType reflect.Type, properties *[]string) {
+	for i := 0; i < modelType.NumField(); i++ {
+		field := modelType.Field(i)
+
+		// 获取字段标签
+		jsonTag := field.Tag.Get("json")
+		if jsonTag == "-" || !field.IsExported() {
+			continue
+		}
+
+		// 展开匿名嵌入的结构体
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && fieldType.Kind() == reflect.Struct && jsonTag == "" {
+			g.collectProperties(fieldType, properties)
+			continue
+		}
+
+		fieldName := strings.Split(jsonTag, ",")[0]
+		if fieldName == "" {
+			fieldName = field.Name
+		}
+
+		// 指针或 omitempty 字段为可选
+		optional := ""
+		if field.Type.Kind() == reflect.Ptr || strings.Contains(jsonTag, ",omitempty") {
+			optional = "?"
+		}
+
+		*properties = append(*properties, fmt.Sprintf("  %s%s: %s;", fieldName, optional, g.convertGoTypeToTSType(field.Type)))
+	}
+}
+
+// convertGoTypeToTSType 将 Go 类型转换为 TypeScript 类型
+func (g *TypeScriptGenerator) convertGoTypeToTSType(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		return g.convertGoTypeToTSType(t.Elem()) + " | null"
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		// []byte 按 base64 字符串序列化
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "string"
+		}
+		elem := g.convertGoTypeToTSType(t.Elem())
+		if strings.Contains(elem, " ") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case reflect.Map:
+		return fmt.Sprintf("Record<string, %s>", g.convertGoTypeToTSType(t.Elem()))
+	case reflect.Struct:
+		if t == reflect.TypeOf(time.Time{}) {
+			return "string"
+		}
+		if t.Name() == "" {
+			return "Record<string, unknown>"
+		}
+		g.generateInterface(t)
+		return t.Name()
+	default:
+		return "unknown"
+	}
+}
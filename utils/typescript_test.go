@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"minigo/models"
+)
+
+// tsOrder TypeScript 测试使用的模型，覆盖指针、切片、映射、时间和嵌套结构体
+type tsOrder struct {
+	models.BaseModel
+	Owner     models.User       `json:"owner"`
+	Items     []*tsOrderItem    `json:"items"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Paid      bool              `json:"paid"`
+	PaidAt    *time.Time        `json:"paid_at"`
+	Raw       []byte            `json:"raw"`
+	Anonymous struct{ A int }   `json:"anonymous"`
+	internal  string
+}
+
+type tsOrderItem struct {
+	Price float64 `json:"price"`
+}
+
+func TestTypeScriptUserInterface(t *testing.T) {
+	g := NewTypeScriptGenerator()
+	g.GenerateTypes(&models.User{})
+
+	// 嵌入的 BaseModel 字段展开，json:"-" 的密码字段不输出
+	want := `export interface User {
+  id: number;
+  created_at: number;
+  updated_at: number;
+  username: string;
+  email: string;
+}
+`
+	if types := g.ReadTypes(); !strings.HasSuffix(types, want) || strings.Contains(types, "password") {
+		t.Fatalf("unexpected User interface:\n%s", types)
+	}
+}
+
+func TestTypeScriptNestedTypes(t *testing.T) {
+	g := NewTypeScriptGenerator()
+	g.GenerateTypes(tsOrder{})
+	// 重复生成的模型只输出一次
+	g.GenerateTypes(models.User{})
+	types := g.ReadTypes()
+
+	for _, line := range []string{
+		"  owner: User;",
+		"  items: (tsOrderItem | null)[];",
+		"  labels?: Record<string, string>;",
+		"  paid: boolean;",
+		"  paid_at?: string | null;",
+		"  raw: string;",
+		"  anonymous: Record<string, unknown>;",
+		"  price: number;",
+	} {
+		if !strings.Contains(types, line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, types)
+		}
+	}
+	if strings.Contains(types, "internal") {
+		t.Errorf("unexported field generated:\n%s", types)
+	}
+	for _, name := range []string{"tsOrder", "tsOrderItem", "User"} {
+		if count := strings.Count(types, "export interface "+name+" {"); count != 1 {
+			t.Errorf("interface %s generated %d times", name, count)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	instanceValidator *validator.Validate
+	onceValidator     sync.Once
+)
+
+// GetValidator 获取校验器实例，字段名使用 json 标签
+func GetValidator() *validator.Validate {
+	onceValidator.Do(func() {
+		instanceValidator = validator.New(validator.WithRequiredStructEnabled())
+		instanceValidator.RegisterTagNameFunc(JSONFieldName)
+	})
+	return instanceValidator
+}
+
+// redactedValue 敏感字段的值在校验错误中的替代值
+const redactedValue = "[REDACTED]"
+
+// FieldError 字段校验错误，供客户端将错误对应到表单字段
+type FieldError struct {
+	Field string      `json:"field"`           // 字段名
+	Rule  string      `json:"rule"`            // 未通过的规则，如 required、oneof、max
+	Param string      `json:"param,omitempty"` // 规则参数，如 max=10 中的 10
+	Value interface{} `json:"value,omitempty"` // 提交的值，敏感字段会被替换
+}
+
+// ValidateStruct 按 validate 标签校验结构体，返回字段校验错误
+func ValidateStruct(v interface{}) ([]FieldError, error) {
+	err := GetValidator().Struct(v)
+	if err == nil {
+		return nil, nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return nil, err
+	}
+
+	modelType := reflect.TypeOf(v)
+	for modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+
+	fieldErrors := make([]FieldError, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		value := fe.Value()
+		if field, ok := lookupStructField(modelType, fe.StructNamespace()); !ok || isSecretField(field) {
+			value = redactedValue
+		}
+		fieldErrors = append(fieldErrors, FieldError{
+			Field: fe.Field(),
+			Rule:  fe.Tag(),
+			Param: fe.Param(),
+			Value: value,
+		})
+	}
+	return fieldErrors, nil
+}
+
+// FieldErrorsMap 将字段校验错误转换为字段名到规则的映射，如 {"name": "min=3"}
+func FieldErrorsMap(fieldErrors []FieldError) map[string]string {
+	fields := make(map[string]string, len(fieldErrors))
+	for _, fe := range fieldErrors {
+		rule := fe.Rule
+		if fe.Param != "" {
+			rule += "=" + fe.Param
+		}
+		fields[fe.Field] = rule
+	}
+	return fields
+}
+
+// lookupStructField 按校验器的结构体命名空间（如 User.Profile.Name）查找字段
+func lookupStructField(modelType reflect.Type, namespace string) (reflect.StructField, bool) {
+	names := strings.Split(namespace, ".")
+	if len(names) < 2 {
+		return reflect.StructField{}, false
+	}
+
+	var field reflect.StructField
+	current := modelType
+	for _, name := range names[1:] {
+		// 切片和映射元素的命名空间形如 Items[0]
+		if i := strings.IndexByte(name, '['); i >= 0 {
+			name = name[:i]
+		}
+		for current.Kind() == reflect.Ptr || current.Kind() == reflect.Slice || current.Kind() == reflect.Map {
+			current = current.Elem()
+		}
+		if current.Kind() != reflect.Struct {
+			return reflect.StructField{}, false
+		}
+		var ok bool
+		if field, ok = current.FieldByName(name); !ok {
+			return reflect.StructField{}, false
+		}
+		current = field.Type
+	}
+	return field, true
+}
+
+// isSecretField 判断字段值是否不应出现在响应中：不参与 JSON 输出、加密存储或密码字段
+func isSecretField(field reflect.StructField) bool {
+	return JSONFieldName(field) == "" || IsEncryptedField(field) || strings.Contains(strings.ToLower(field.Name), "password")
+}